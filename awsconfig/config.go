@@ -0,0 +1,76 @@
+package awsconfig
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Config descreve como obter as credenciais AWS compartilhadas por queue e
+// bucket. Sem AccessKey, usa a cadeia padrao do SDK: variaveis de ambiente,
+// profile do ~/.aws, web identity (IRSA no EKS), role da task ECS e role da
+// instancia EC2.
+type Config struct {
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Profile      string // profile do ~/.aws/config; vazio usa o padrao
+
+	// AssumeRoleArn, quando definido, assume a role a partir das credenciais
+	// acima. As credenciais temporarias sao renovadas automaticamente.
+	AssumeRoleArn   string
+	RoleSessionName string // padrao "go-data-connector-lib"
+	ExternalId      string
+
+	// Endpoint substitui o endpoint de todos os servicos, ex.:
+	// http://localhost:4566 para o LocalStack.
+	Endpoint string
+}
+
+// Load monta o aws.Config. Crie-o uma vez e reutilize nos clients, pois a
+// resolucao de credenciais e os caches ficam nele.
+func Load(ctx context.Context, cfg Config) (aws.Config, error) {
+	var opts []func(*config.LoadOptions) error
+
+	if cfg.Region != "" {
+		opts = append(opts, config.WithRegion(cfg.Region))
+	}
+	if cfg.AccessKey != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, cfg.SessionToken),
+		))
+	}
+	if cfg.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(cfg.Profile))
+	}
+	if cfg.Endpoint != "" {
+		opts = append(opts, config.WithBaseEndpoint(cfg.Endpoint))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	if cfg.AssumeRoleArn != "" {
+		sessionName := cfg.RoleSessionName
+		if sessionName == "" {
+			sessionName = "go-data-connector-lib"
+		}
+
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsCfg), cfg.AssumeRoleArn, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = sessionName
+			if cfg.ExternalId != "" {
+				o.ExternalID = aws.String(cfg.ExternalId)
+			}
+		})
+		awsCfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	return awsCfg, nil
+}
@@ -0,0 +1,165 @@
+package awsconfig
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// isolate remove as credenciais do ambiente e aponta os arquivos do ~/.aws
+// para um diretorio temporario com o profile "dev".
+func isolate(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ROLE_ARN", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ENDPOINT_URL"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials")
+	configFile := filepath.Join(dir, "config")
+	os.WriteFile(credentialsFile, []byte("[dev]\naws_access_key_id = profile-key\naws_secret_access_key = profile-secret\n"), 0o600)
+	os.WriteFile(configFile, []byte("[profile dev]\nregion = sa-east-1\n"), 0o600)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+}
+
+func retrieve(t *testing.T, cfg aws.Config) aws.Credentials {
+	t.Helper()
+	credentials, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	return credentials
+}
+
+func TestLoadStaticKeysOverrideProfile(t *testing.T) {
+	isolate(t)
+
+	cfg, err := Load(context.Background(), Config{Profile: "dev", AccessKey: "static-key", SecretKey: "static-secret", SessionToken: "token"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	credentials := retrieve(t, cfg)
+	if credentials.AccessKeyID != "static-key" || credentials.SecretAccessKey != "static-secret" || credentials.SessionToken != "token" {
+		t.Fatalf("credentials = %+v, want the static keys", credentials)
+	}
+	if cfg.Region != "sa-east-1" {
+		t.Fatalf("region = %q, want the profile region", cfg.Region)
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	isolate(t)
+
+	cfg, err := Load(context.Background(), Config{Profile: "dev", Region: "us-east-1"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if credentials := retrieve(t, cfg); credentials.AccessKeyID != "profile-key" {
+		t.Fatalf("access key = %q, want the profile key", credentials.AccessKeyID)
+	}
+	if cfg.Region != "us-east-1" {
+		t.Fatalf("region = %q, want the explicit region to win", cfg.Region)
+	}
+
+	if _, err := Load(context.Background(), Config{Profile: "inexistente"}); err == nil {
+		t.Fatal("expected error for a missing profile")
+	}
+}
+
+func TestLoadBaseEndpoint(t *testing.T) {
+	isolate(t)
+
+	cfg, err := Load(context.Background(), Config{Region: "us-east-1", AccessKey: "k", SecretKey: "s", Endpoint: "http://localhost:4566"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if aws.ToString(cfg.BaseEndpoint) != "http://localhost:4566" {
+		t.Fatalf("BaseEndpoint = %q", aws.ToString(cfg.BaseEndpoint))
+	}
+
+	cfg, _ = Load(context.Background(), Config{Region: "us-east-1", AccessKey: "k", SecretKey: "s"})
+	if cfg.BaseEndpoint != nil {
+		t.Fatalf("BaseEndpoint = %q, want nil without Endpoint", aws.ToString(cfg.BaseEndpoint))
+	}
+}
+
+func TestLoadAssumeRole(t *testing.T) {
+	isolate(t)
+
+	var requests []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, map[string]string{
+			"Action":          r.Form.Get("Action"),
+			"RoleArn":         r.Form.Get("RoleArn"),
+			"RoleSessionName": r.Form.Get("RoleSessionName"),
+			"ExternalId":      r.Form.Get("ExternalId"),
+		})
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(`<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>assumed-key</AccessKeyId>
+      <SecretAccessKey>assumed-secret</SecretAccessKey>
+      <SessionToken>assumed-token</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::123456789012:assumed-role/leitura/sessao</Arn>
+      <AssumedRoleId>AROA:sessao</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		config      Config
+		sessionName string
+		externalId  string
+	}{
+		{"custom session and external id", Config{RoleSessionName: "sessao", ExternalId: "ext-123"}, "sessao", "ext-123"},
+		{"defaults", Config{}, "go-data-connector-lib", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			config := tt.config
+			config.Region = "us-east-1"
+			config.AccessKey, config.SecretKey = "base-key", "base-secret"
+			config.AssumeRoleArn = "arn:aws:iam::123456789012:role/leitura"
+			config.Endpoint = server.URL
+
+			cfg, err := Load(context.Background(), config)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			credentials := retrieve(t, cfg)
+			if credentials.AccessKeyID != "assumed-key" || credentials.SessionToken != "assumed-token" {
+				t.Fatalf("credentials = %+v, want the assumed role", credentials)
+			}
+
+			// As credenciais ficam em cache ate expirar.
+			retrieve(t, cfg)
+			if len(requests) != 1 {
+				t.Fatalf("AssumeRole calls = %d, want 1", len(requests))
+			}
+			request := requests[0]
+			if request["Action"] != "AssumeRole" || request["RoleArn"] != config.AssumeRoleArn {
+				t.Fatalf("request = %v", request)
+			}
+			if request["RoleSessionName"] != tt.sessionName || request["ExternalId"] != tt.externalId {
+				t.Fatalf("session = %q, external id = %q; want %q, %q", request["RoleSessionName"], request["ExternalId"], tt.sessionName, tt.externalId)
+			}
+		})
+	}
+}
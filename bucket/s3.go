@@ -8,9 +8,8 @@ import (
 	"os"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/simpplify-org/GO-data-connector-lib/awsconfig"
 )
 
 type ToS3 struct {
@@ -19,36 +18,28 @@ type ToS3 struct {
 }
 
 func NewToS3(AwsAccessKey, AwsSecretKey, AwsRegion, BucketName string, isTest bool) (*ToS3, error) {
-	var cfg aws.Config
-	var err error
-
+	awsCfg := awsconfig.Config{
+		Region:    AwsRegion,
+		AccessKey: AwsAccessKey,
+		SecretKey: AwsSecretKey,
+	}
 	if isTest {
-		endpoint := "http://localhost:4566"
-		cfg, err = config.LoadDefaultConfig(context.TODO(),
-			config.WithRegion(AwsRegion),
-			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(AwsAccessKey, AwsSecretKey, "")),
-			config.WithEndpointResolver(aws.EndpointResolverFunc(
-				func(service, region string) (aws.Endpoint, error) {
-					return aws.Endpoint{
-						URL:           endpoint,
-						SigningRegion: AwsRegion,
-					}, nil
-				},
-			)),
-		)
-	} else {
-		cfg, err = config.LoadDefaultConfig(context.TODO(),
-			config.WithRegion(AwsRegion),
-			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(AwsAccessKey, AwsSecretKey, "")),
-		)
+		awsCfg.Endpoint = "http://localhost:4566"
 	}
 
+	cfg, err := awsconfig.Load(context.TODO(), awsCfg)
 	if err != nil {
 		return nil, err
 	}
 
+	return NewToS3FromConfig(cfg, BucketName), nil
+}
+
+// NewToS3FromConfig usa um aws.Config ja montado, normalmente por
+// awsconfig.Load. Com endpoint customizado (LocalStack), usa path-style.
+func NewToS3FromConfig(cfg aws.Config, BucketName string) *ToS3 {
 	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
-		if isTest {
+		if cfg.BaseEndpoint != nil {
 			o.UsePathStyle = true
 		}
 	})
@@ -56,7 +47,7 @@ func NewToS3(AwsAccessKey, AwsSecretKey, AwsRegion, BucketName string, isTest bo
 	return &ToS3{
 		client:     client,
 		BucketName: BucketName,
-	}, nil
+	}
 }
 
 func (b *ToS3) CreateBucket(ctx context.Context) error {
@@ -115,6 +106,28 @@ func (b *ToS3) DownloadFile(ctx context.Context, key, filePath string) error {
 	return nil
 }
 
+func (b *ToS3) UploadBytes(ctx context.Context, key string, data []byte) error {
+	_, err := b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(b.BucketName),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (b *ToS3) DownloadBytes(ctx context.Context, key string) ([]byte, error) {
+	resp, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
 func (b *ToS3) DeleteFile(ctx context.Context, key string) error {
 	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(b.BucketName),
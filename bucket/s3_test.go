@@ -0,0 +1,41 @@
+package bucket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestNewToS3FromConfigUsesPathStyleWithBaseEndpoint(t *testing.T) {
+	var host, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, path = r.Host, r.URL.Path
+	}))
+	defer server.Close()
+
+	cfg := aws.Config{
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("test", "test", ""),
+		BaseEndpoint: aws.String(server.URL),
+	}
+	s3 := NewToS3FromConfig(cfg, "meu-bucket")
+	if err := s3.UploadBytes(context.Background(), "pasta/arquivo.txt", []byte("conteudo")); err != nil {
+		t.Fatalf("UploadBytes: %v", err)
+	}
+
+	if host != server.Listener.Addr().String() || path != "/meu-bucket/pasta/arquivo.txt" {
+		t.Fatalf("request to %s%s, want path-style on the endpoint host", host, path)
+	}
+}
+
+func TestNewToS3FromConfigUsesVirtualHostWithoutEndpoint(t *testing.T) {
+	cfg := aws.Config{Region: "us-east-1", Credentials: credentials.NewStaticCredentialsProvider("test", "test", "")}
+	s3 := NewToS3FromConfig(cfg, "meu-bucket")
+	if s3.client.Options().UsePathStyle {
+		t.Fatal("UsePathStyle enabled without a custom endpoint")
+	}
+}
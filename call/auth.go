@@ -0,0 +1,192 @@
+package call
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authenticator adiciona credenciais a cada tentativa de envio da requisicao.
+type Authenticator interface {
+	Authenticate(ctx context.Context, req *http.Request) error
+}
+
+// Refresher e implementado por autenticadores cujas credenciais podem ser
+// renovadas. Quando a resposta e 401, o client invalida a credencial usada
+// e repete a requisicao uma unica vez.
+type Refresher interface {
+	Invalidate(req *http.Request)
+}
+
+type BearerAuth struct {
+	Token string
+}
+
+func (a BearerAuth) Authenticate(_ context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (a BasicAuth) Authenticate(_ context.Context, req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// APIKeyAuth envia a chave no cabecalho Name ou, com InQuery, no parametro
+// de query Name.
+type APIKeyAuth struct {
+	Name    string
+	Value   string
+	InQuery bool
+}
+
+func (a APIKeyAuth) Authenticate(_ context.Context, req *http.Request) error {
+	if !a.InQuery {
+		req.Header.Set(a.Name, a.Value)
+		return nil
+	}
+
+	query := req.URL.Query()
+	query.Set(a.Name, a.Value)
+	req.URL.RawQuery = query.Encode()
+	return nil
+}
+
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	Params       map[string]string // parametros extras do token endpoint, ex.: audience
+	AuthInBody   bool              // envia client_id/client_secret no corpo em vez de basic auth
+	ExpiryDelta  time.Duration     // padrao 30 segundos de antecedencia na renovacao
+	HTTPClient   *http.Client      // padrao timeout de 30 segundos
+}
+
+// OAuth2ClientCredentials obtem tokens pelo fluxo client credentials e os
+// mantem em cache ate perto da expiracao.
+type OAuth2ClientCredentials struct {
+	config OAuth2Config
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func NewOAuth2ClientCredentials(config OAuth2Config) *OAuth2ClientCredentials {
+	if config.ExpiryDelta <= 0 {
+		config.ExpiryDelta = 30 * time.Second
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second, Transport: sharedTransport}
+	}
+
+	return &OAuth2ClientCredentials{config: config}
+}
+
+func (a *OAuth2ClientCredentials) Authenticate(ctx context.Context, req *http.Request) error {
+	token, err := a.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (a *OAuth2ClientCredentials) Invalidate(req *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if req.Header.Get("Authorization") == "Bearer "+a.token {
+		a.token = ""
+		a.expiry = time.Time{}
+	}
+}
+
+// Token devolve o token em cache ou busca um novo no TokenURL.
+func (a *OAuth2ClientCredentials) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && (a.expiry.IsZero() || time.Now().Add(a.config.ExpiryDelta).Before(a.expiry)) {
+		return a.token, nil
+	}
+
+	token, err := a.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	a.token = token.AccessToken
+	a.expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		a.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return a.token, nil
+}
+
+func (a *OAuth2ClientCredentials) fetch(ctx context.Context) (*oauth2Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(a.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.config.Scopes, " "))
+	}
+	for key, value := range a.config.Params {
+		form.Set(key, value)
+	}
+	if a.config.AuthInBody {
+		form.Set("client_id", a.config.ClientID)
+		form.Set("client_secret", a.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oauth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !a.config.AuthInBody {
+		req.SetBasicAuth(url.QueryEscape(a.config.ClientID), url.QueryEscape(a.config.ClientSecret))
+	}
+
+	resp, err := a.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch oauth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read oauth2 token response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to fetch oauth2 token: %w", &ResponseError{StatusCode: resp.StatusCode, RawBody: rawBody})
+	}
+
+	var token oauth2Token
+	if err := json.Unmarshal(rawBody, &token); err != nil {
+		return nil, fmt.Errorf("failed to decode oauth2 token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("failed to fetch oauth2 token: empty access_token")
+	}
+	return &token, nil
+}
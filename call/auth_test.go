@@ -0,0 +1,148 @@
+package call
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStaticAuthenticators(t *testing.T) {
+	tests := []struct {
+		name  string
+		auth  Authenticator
+		check func(r *http.Request) bool
+	}{
+		{"bearer", BearerAuth{Token: "t"}, func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer t" }},
+		{"basic", BasicAuth{Username: "u", Password: "p"}, func(r *http.Request) bool {
+			user, pass, ok := r.BasicAuth()
+			return ok && user == "u" && pass == "p"
+		}},
+		{"api key header", APIKeyAuth{Name: "X-Api-Key", Value: "k"}, func(r *http.Request) bool { return r.Header.Get("X-Api-Key") == "k" }},
+		{"api key query", APIKeyAuth{Name: "key", Value: "k", InQuery: true}, func(r *http.Request) bool {
+			return r.URL.Query().Get("key") == "k" && r.URL.Query().Get("page") == "2"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !tt.check(r) {
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer server.Close()
+
+			client := NewClient(ClientConfig{Auth: tt.auth})
+			if _, err := client.Do(context.Background(), Request{URL: server.URL + "?page=2"}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func newTokenServer(t *testing.T, issued *atomic.Int32, expiresIn int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if r.FormValue("grant_type") != "client_credentials" || user != "id" || pass != "secret" || r.FormValue("scope") != "read write" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n := issued.Add(1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, n, expiresIn)
+	}))
+}
+
+func TestOAuth2ClientCredentialsCachesToken(t *testing.T) {
+	var issued atomic.Int32
+	tokenServer := newTokenServer(t, &issued, 3600)
+	defer tokenServer.Close()
+
+	auth := NewOAuth2ClientCredentials(OAuth2Config{TokenURL: tokenServer.URL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"read", "write"}})
+	for i := 0; i < 3; i++ {
+		token, err := auth.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "token-1" {
+			t.Fatalf("token = %q, want cached token-1", token)
+		}
+	}
+
+	// expires_in menor que ExpiryDelta obriga a buscar um novo token.
+	var shortIssued atomic.Int32
+	shortServer := newTokenServer(t, &shortIssued, 10)
+	defer shortServer.Close()
+	short := NewOAuth2ClientCredentials(OAuth2Config{TokenURL: shortServer.URL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"read", "write"}})
+	short.Token(context.Background())
+	if token, _ := short.Token(context.Background()); token != "token-2" {
+		t.Fatalf("token = %q, want refreshed token-2", token)
+	}
+}
+
+func TestOAuth2ClientCredentialsTokenError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid_client"}`))
+	}))
+	defer server.Close()
+
+	auth := NewOAuth2ClientCredentials(OAuth2Config{TokenURL: server.URL, ClientID: "id", ClientSecret: "bad"})
+	if _, err := auth.Token(context.Background()); err == nil {
+		t.Fatal("expected error for rejected credentials")
+	}
+}
+
+func TestClientRefreshesTokenOn401(t *testing.T) {
+	var issued atomic.Int32
+	tokenServer := newTokenServer(t, &issued, 3600)
+	defer tokenServer.Close()
+
+	var calls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// o primeiro token foi revogado no servidor antes de expirar
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+
+	auth := NewOAuth2ClientCredentials(OAuth2Config{TokenURL: tokenServer.URL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"read", "write"}})
+	client := NewClient(ClientConfig{Auth: auth})
+
+	resp, err := client.Do(context.Background(), Request{Method: http.MethodPost, URL: api.URL, Body: map[string]int{"n": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 || issued.Load() != 2 {
+		t.Fatalf("status %d, api calls %d, tokens %d; want 200, 2, 2", resp.StatusCode, calls.Load(), issued.Load())
+	}
+}
+
+func TestClientRefreshesOnlyOnce(t *testing.T) {
+	var issued, calls atomic.Int32
+	tokenServer := newTokenServer(t, &issued, 3600)
+	defer tokenServer.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer api.Close()
+
+	auth := NewOAuth2ClientCredentials(OAuth2Config{TokenURL: tokenServer.URL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"read", "write"}})
+	client := NewClient(ClientConfig{Auth: auth})
+
+	resp, err := client.Do(context.Background(), Request{URL: api.URL})
+	if err == nil && resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", resp.StatusCode)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("api calls = %d, want a single retry after 401", calls.Load())
+	}
+}
@@ -0,0 +1,209 @@
+package call
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type BreakerState int
+
+const (
+	StateClosed BreakerState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+type BreakerConfig struct {
+	ConsecutiveFailures int           // padrao 5
+	FailureRatio        float64       // padrao 0 (desativado); ex.: 0.5 abre com 50% de falhas
+	MinRequests         int           // padrao 10 requisicoes antes de avaliar FailureRatio
+	Interval            time.Duration // padrao 60 segundos, janela das contagens no estado fechado
+	CoolDown            time.Duration // padrao 30 segundos em aberto antes de testar o host
+	HalfOpenRequests    int           // padrao 1 requisicao de teste no estado semiaberto
+	// IsFailure substitui a regra padrao (erros de rede e 5xx).
+	IsFailure     func(resp *http.Response, err error) bool
+	OnStateChange func(host string, from, to BreakerState)
+}
+
+func (c *BreakerConfig) validate() {
+	if c.ConsecutiveFailures <= 0 {
+		c.ConsecutiveFailures = 5
+	}
+	if c.MinRequests <= 0 {
+		c.MinRequests = 10
+	}
+	if c.Interval <= 0 {
+		c.Interval = 60 * time.Second
+	}
+	if c.CoolDown <= 0 {
+		c.CoolDown = 30 * time.Second
+	}
+	if c.HalfOpenRequests <= 0 {
+		c.HalfOpenRequests = 1
+	}
+}
+
+func (c BreakerConfig) isFailure(resp *http.Response, err error) bool {
+	if c.IsFailure != nil {
+		return c.IsFailure(resp, err)
+	}
+	return err != nil || resp.StatusCode >= 500
+}
+
+type Breaker struct {
+	config BreakerConfig
+	host   string
+
+	mu          sync.Mutex
+	state       BreakerState
+	generation  uint64
+	expiry      time.Time
+	requests    int
+	failures    int
+	consecutive int
+	inFlight    int
+	successes   int
+}
+
+func NewBreaker(host string, config BreakerConfig) *Breaker {
+	config.validate()
+
+	b := &Breaker{config: config, host: host}
+	b.reset(time.Now())
+	return b
+}
+
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(time.Now())
+	return b.state
+}
+
+// allow reserva uma tentativa e devolve a geracao em que ela foi feita, para
+// que resultados de um ciclo anterior sejam descartados em done.
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(time.Now())
+
+	switch b.state {
+	case StateOpen:
+		return 0, ErrCircuitOpen
+	case StateHalfOpen:
+		if b.inFlight >= b.config.HalfOpenRequests {
+			return 0, ErrCircuitOpen
+		}
+		b.inFlight++
+	}
+
+	b.requests++
+	return b.generation, nil
+}
+
+// done registra o resultado da tentativa. Com counted false a tentativa e
+// apenas liberada, sem contar como sucesso ou falha.
+func (b *Breaker) done(generation uint64, counted, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.refresh(now)
+	if generation != b.generation {
+		return
+	}
+
+	if b.state == StateHalfOpen {
+		b.inFlight--
+	}
+	if !counted {
+		b.requests--
+		return
+	}
+
+	if failed {
+		b.failures++
+		b.consecutive++
+		if b.state == StateHalfOpen || b.tripped() {
+			b.setState(StateOpen, now)
+		}
+		return
+	}
+
+	b.consecutive = 0
+	if b.state == StateHalfOpen {
+		b.successes++
+		if b.successes >= b.config.HalfOpenRequests {
+			b.setState(StateClosed, now)
+		}
+	}
+}
+
+func (b *Breaker) tripped() bool {
+	if b.consecutive >= b.config.ConsecutiveFailures {
+		return true
+	}
+	if b.config.FailureRatio > 0 && b.requests >= b.config.MinRequests {
+		return float64(b.failures)/float64(b.requests) >= b.config.FailureRatio
+	}
+	return false
+}
+
+func (b *Breaker) refresh(now time.Time) {
+	switch b.state {
+	case StateClosed:
+		if now.After(b.expiry) {
+			b.reset(now)
+		}
+	case StateOpen:
+		if now.After(b.expiry) {
+			b.setState(StateHalfOpen, now)
+		}
+	}
+}
+
+func (b *Breaker) setState(state BreakerState, now time.Time) {
+	previous := b.state
+	b.state = state
+	b.reset(now)
+
+	if b.config.OnStateChange != nil && previous != state {
+		go b.config.OnStateChange(b.host, previous, state)
+	}
+}
+
+func (b *Breaker) reset(now time.Time) {
+	b.generation++
+	b.requests = 0
+	b.failures = 0
+	b.consecutive = 0
+	b.inFlight = 0
+	b.successes = 0
+
+	switch b.state {
+	case StateClosed:
+		b.expiry = now.Add(b.config.Interval)
+	case StateOpen:
+		b.expiry = now.Add(b.config.CoolDown)
+	default:
+		b.expiry = time.Time{}
+	}
+}
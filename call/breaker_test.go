@@ -0,0 +1,145 @@
+package call
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	b := NewBreaker("api", BreakerConfig{ConsecutiveFailures: 2, CoolDown: 20 * time.Millisecond})
+
+	for i := 0; i < 2; i++ {
+		generation, err := b.allow()
+		if err != nil {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+		b.done(generation, true, true)
+	}
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestBreakerHalfOpenRecoversOrReopens(t *testing.T) {
+	b := NewBreaker("api", BreakerConfig{ConsecutiveFailures: 1, CoolDown: 20 * time.Millisecond})
+	trip := func() {
+		generation, err := b.allow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.done(generation, true, true)
+	}
+
+	trip()
+	time.Sleep(30 * time.Millisecond)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %s, want half-open", b.State())
+	}
+
+	generation, err := b.allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second half-open attempt err = %v, want ErrCircuitOpen", err)
+	}
+	b.done(generation, true, true)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open after half-open failure", b.State())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	generation, err = b.allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.done(generation, true, false)
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want closed after half-open success", b.State())
+	}
+}
+
+func TestBreakerFailureRatio(t *testing.T) {
+	b := NewBreaker("api", BreakerConfig{ConsecutiveFailures: 100, FailureRatio: 0.5, MinRequests: 4})
+
+	for _, failed := range []bool{false, true, false} {
+		generation, _ := b.allow()
+		b.done(generation, true, failed)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s before MinRequests, want closed", b.State())
+	}
+
+	generation, _ := b.allow()
+	b.done(generation, true, true)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s at 50%% failures, want open", b.State())
+	}
+}
+
+func TestBreakerIgnoresUncountedAndStaleResults(t *testing.T) {
+	b := NewBreaker("api", BreakerConfig{ConsecutiveFailures: 1, CoolDown: time.Hour})
+
+	generation, _ := b.allow()
+	b.done(generation, false, true)
+	if b.State() != StateClosed {
+		t.Fatalf("uncounted failure opened the breaker")
+	}
+
+	stale, _ := b.allow()
+	generation, _ = b.allow()
+	b.done(generation, true, true)
+	b.done(stale, true, false)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, stale result changed the new cycle", b.State())
+	}
+}
+
+func TestClientBreakerRejectsOpenHost(t *testing.T) {
+	var calls atomic.Int32
+	changes := make(chan BreakerState, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{Breaker: &BreakerConfig{
+		ConsecutiveFailures: 2,
+		CoolDown:            time.Hour,
+		OnStateChange:       func(host string, from, to BreakerState) { changes <- to },
+	}})
+
+	for i := 0; i < 3; i++ {
+		client.Do(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+	}
+	_, err := client.Do(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("server calls = %d, want 2", calls.Load())
+	}
+
+	host, _ := url.Parse(server.URL)
+	if client.BreakerState(host.Host) != StateOpen {
+		t.Fatalf("BreakerState = %s, want open", client.BreakerState(host.Host))
+	}
+	select {
+	case state := <-changes:
+		if state != StateOpen {
+			t.Fatalf("OnStateChange to = %s, want open", state)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnStateChange not called")
+	}
+}
@@ -0,0 +1,350 @@
+package call
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var ErrInteractionNotFound = errors.New("cassette: no recorded interaction matches the request")
+
+type CassetteMode int
+
+const (
+	// ModeReplay responde apenas com interacoes gravadas, sem acessar a rede.
+	ModeReplay CassetteMode = iota
+	// ModeRecord executa as requisicoes reais e grava todas as interacoes.
+	ModeRecord
+	// ModeReplayOrRecord usa a gravacao quando existe e grava o que faltar.
+	ModeReplayOrRecord
+)
+
+type RecordedRequest struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" para corpos binarios
+}
+
+type RecordedResponse struct {
+	StatusCode   int         `json:"status_code"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Matcher decide se uma interacao gravada corresponde a requisicao atual.
+type Matcher func(req *http.Request, body []byte, recorded RecordedRequest) bool
+
+func MatchMethod(req *http.Request, _ []byte, recorded RecordedRequest) bool {
+	return req.Method == recorded.Method
+}
+
+func MatchURL(req *http.Request, _ []byte, recorded RecordedRequest) bool {
+	return req.URL.String() == recorded.URL
+}
+
+func MatchBody(_ *http.Request, body []byte, recorded RecordedRequest) bool {
+	return bytes.Equal(body, recorded.body())
+}
+
+// MatchJSONBody compara os corpos como JSON, ignorando ordem de campos e
+// espacos.
+func MatchJSONBody(_ *http.Request, body []byte, recorded RecordedRequest) bool {
+	var current, expected interface{}
+	if json.Unmarshal(body, &current) != nil || json.Unmarshal(recorded.body(), &expected) != nil {
+		return bytes.Equal(body, recorded.body())
+	}
+	return reflect.DeepEqual(current, expected)
+}
+
+func MatchHeaders(names ...string) Matcher {
+	return func(req *http.Request, _ []byte, recorded RecordedRequest) bool {
+		for _, name := range names {
+			if req.Header.Get(name) != recorded.Headers.Get(name) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+type CassetteConfig struct {
+	Path      string
+	Mode      CassetteMode
+	Transport http.RoundTripper // transport real usado na gravacao; padrao transport compartilhado
+	Matchers  []Matcher         // padrao MatchMethod e MatchURL
+	// RedactHeaders nao sao gravados no arquivo; padrao Authorization,
+	// Proxy-Authorization, Cookie, Set-Cookie e X-Api-Key.
+	RedactHeaders []string
+	// RedactQuery sao parametros de query mascarados na URL gravada, como
+	// chaves enviadas por APIKeyAuth{InQuery: true}; padrao api_key, apikey,
+	// api-key, x-api-key, key, access_token, token, auth, client_secret,
+	// password, signature e sig. No replay a URL atual e mascarada da mesma
+	// forma antes de comparar.
+	RedactQuery []string
+	// AllowRepeats permite reutilizar uma interacao ja consumida quando nao
+	// ha outra disponivel; por padrao cada interacao responde uma unica vez.
+	AllowRepeats bool
+}
+
+// Cassette e um http.RoundTripper que grava e reproduz interacoes HTTP em um
+// arquivo JSON, para testes de integracao sem acesso aos servicos reais.
+type Cassette struct {
+	config CassetteConfig
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+	dirty        bool
+}
+
+func NewCassette(config CassetteConfig) (*Cassette, error) {
+	if config.Transport == nil {
+		config.Transport = sharedTransport
+	}
+	if len(config.Matchers) == 0 {
+		config.Matchers = []Matcher{MatchMethod, MatchURL}
+	}
+	if config.RedactHeaders == nil {
+		config.RedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	}
+	if config.RedactQuery == nil {
+		config.RedactQuery = []string{
+			"api_key", "apikey", "api-key", "x-api-key", "key", "access_token", "token",
+			"auth", "client_secret", "password", "signature", "sig",
+		}
+	}
+
+	cassette := &Cassette{config: config}
+	if config.Mode == ModeRecord {
+		return cassette, nil
+	}
+
+	data, err := os.ReadFile(config.Path)
+	if errors.Is(err, os.ErrNotExist) && config.Mode == ModeReplayOrRecord {
+		return cassette, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cassette: failed to read %s: %w", config.Path, err)
+	}
+	if err := json.Unmarshal(data, &cassette.interactions); err != nil {
+		return nil, fmt.Errorf("cassette: failed to decode %s: %w", config.Path, err)
+	}
+	cassette.used = make([]bool, len(cassette.interactions))
+
+	return cassette, nil
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: failed to read request body: %w", err)
+	}
+
+	if c.config.Mode != ModeRecord {
+		if interaction, ok := c.match(req, body); ok {
+			return interaction.Response.toHTTP(req), nil
+		}
+		if c.config.Mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL)
+		}
+	}
+
+	realReq := req.Clone(req.Context())
+	if body != nil {
+		realReq.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := c.config.Transport.RoundTrip(realReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: failed to read response body: %w", err)
+	}
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     c.redactURL(req.URL),
+			Headers: c.redact(req.Header),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    c.redact(resp.Header),
+		},
+	}
+	interaction.Request.Body, interaction.Request.BodyEncoding = encodeBody(body)
+	interaction.Response.Body, interaction.Response.BodyEncoding = encodeBody(respBody)
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, interaction)
+	c.used = append(c.used, true)
+	c.dirty = true
+	c.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// Save grava as interacoes no arquivo quando houve novas gravacoes.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cassette: failed to encode interactions: %w", err)
+	}
+	if err := os.WriteFile(c.config.Path, data, 0o644); err != nil {
+		return fmt.Errorf("cassette: failed to write %s: %w", c.config.Path, err)
+	}
+	c.dirty = false
+	return nil
+}
+
+func (c *Cassette) match(req *http.Request, body []byte) (Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Os matchers veem a URL mascarada, igual a gravada no arquivo.
+	req = req.Clone(req.Context())
+	req.URL, _ = url.Parse(c.redactURL(req.URL))
+
+	repeat := -1
+	for i, interaction := range c.interactions {
+		if !c.matches(req, body, interaction.Request) {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return interaction, true
+		}
+		repeat = i
+	}
+
+	if repeat >= 0 && c.config.AllowRepeats {
+		return c.interactions[repeat], true
+	}
+	return Interaction{}, false
+}
+
+func (c *Cassette) matches(req *http.Request, body []byte, recorded RecordedRequest) bool {
+	for _, matcher := range c.config.Matchers {
+		if !matcher(req, body, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Cassette) redact(header http.Header) http.Header {
+	clone := header.Clone()
+	for key := range clone {
+		for _, name := range c.config.RedactHeaders {
+			if strings.EqualFold(key, name) {
+				clone.Del(key)
+			}
+		}
+	}
+	return clone
+}
+
+func (c *Cassette) redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+
+	query := u.Query()
+	changed := false
+	for key, values := range query {
+		for _, name := range c.config.RedactQuery {
+			if strings.EqualFold(key, name) {
+				for i := range values {
+					values[i] = redacted
+				}
+				changed = true
+				break
+			}
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+
+	clone := *u
+	clone.RawQuery = query.Encode()
+	return clone.String()
+}
+
+func (r RecordedRequest) body() []byte {
+	return decodeBody(r.Body, r.BodyEncoding)
+}
+
+func (r RecordedResponse) toHTTP(req *http.Request) *http.Response {
+	body := decodeBody(r.Body, r.BodyEncoding)
+	header := r.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func decodeBody(body, encoding string) []byte {
+	if encoding == "base64" {
+		data, err := base64.StdEncoding.DecodeString(body)
+		if err == nil {
+			return data
+		}
+	}
+	return []byte(body)
+}
@@ -0,0 +1,135 @@
+package call
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder, err := NewCassette(CassetteConfig{Path: path, Mode: ModeRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(ClientConfig{
+		Transport: recorder,
+		Auth:      APIKeyAuth{Name: "api_key", Value: "query-secret", InQuery: true},
+		Headers:   map[string]string{"X-Api-Key": "header-secret"},
+	})
+	if _, err := client.Do(context.Background(), Request{Method: http.MethodGet, URL: server.URL + "/users?page=1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "query-secret") || strings.Contains(string(data), "header-secret") {
+		t.Fatalf("cassette leaked a secret: %s", data)
+	}
+	if !strings.Contains(string(data), "page=1") {
+		t.Fatalf("cassette lost a regular query parameter: %s", data)
+	}
+
+	// Replay sem servidor: a chave diferente tambem e mascarada no match.
+	player, err := NewCassette(CassetteConfig{Path: path, Mode: ModeReplay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = NewClient(ClientConfig{
+		Transport: player,
+		Auth:      APIKeyAuth{Name: "api_key", Value: "another-secret", InQuery: true},
+	})
+	resp, err := client.Do(context.Background(), Request{Method: http.MethodGet, URL: server.URL + "/users?page=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits != 1 || string(resp.RawBody) != `{"path":"/users"}` {
+		t.Fatalf("replay hits=%d body=%s", hits, resp.RawBody)
+	}
+
+	// Cada interacao responde uma unica vez sem AllowRepeats.
+	_, err = client.Do(context.Background(), Request{Method: http.MethodGet, URL: server.URL + "/users?page=1"})
+	if !errors.Is(err, ErrInteractionNotFound) {
+		t.Fatalf("second replay err = %v, want ErrInteractionNotFound", err)
+	}
+}
+
+func TestCassetteMatchers(t *testing.T) {
+	recorded := RecordedRequest{
+		Method:  http.MethodPost,
+		URL:     "http://example.com/a",
+		Headers: http.Header{"X-Tenant": []string{"1"}},
+		Body:    `{"b":2,"a":1}`,
+	}
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/a", nil)
+	req.Header.Set("X-Tenant", "1")
+
+	if !MatchJSONBody(req, []byte(`{"a":1, "b":2}`), recorded) {
+		t.Fatal("MatchJSONBody should ignore key order and spaces")
+	}
+	if MatchBody(req, []byte(`{"a":1, "b":2}`), recorded) {
+		t.Fatal("MatchBody should compare bytes")
+	}
+	if !MatchHeaders("X-Tenant")(req, nil, recorded) {
+		t.Fatal("MatchHeaders should match equal headers")
+	}
+	req.Header.Set("X-Tenant", "2")
+	if MatchHeaders("X-Tenant")(req, nil, recorded) {
+		t.Fatal("MatchHeaders should reject different headers")
+	}
+}
+
+func TestCassetteReplayOrRecordAndRepeats(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte{0xff, 0xfe, 0x00}) // corpo binario gravado em base64
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette, err := NewCassette(CassetteConfig{Path: path, Mode: ModeReplayOrRecord, AllowRepeats: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(ClientConfig{Transport: cassette})
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Do(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(resp.RawBody) != string([]byte{0xff, 0xfe, 0x00}) {
+			t.Fatalf("body = %v", resp.RawBody)
+		}
+	}
+	if hits != 1 {
+		t.Fatalf("server hits = %d, want 1", hits)
+	}
+	if err := cassette.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"body_encoding": "base64"`) {
+		t.Fatalf("binary body not base64 encoded: %s", data)
+	}
+}
@@ -0,0 +1,384 @@
+package call
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var sharedTransport = http.DefaultTransport.(*http.Transport).Clone()
+
+type ClientConfig struct {
+	BaseURL   string
+	Headers   map[string]string
+	Timeout   time.Duration     // padrao 30 segundos
+	Transport http.RoundTripper // padrao transport compartilhado entre clients
+	Retry     RetryPolicy
+	Breaker   *BreakerConfig // circuit breaker por host; nil desativa
+	Encoder   Encoder        // padrao JSONEncoder
+	Auth      Authenticator
+	RateLimit *RateLimitConfig // nil desativa
+	// MaxBodySize limita o tamanho da resposta lida pelo client; 0 nao limita.
+	MaxBodySize int64
+	// Idempotency gera chaves de idempotencia para metodos nao idempotentes,
+	// que so sao repetidos pela politica de retry quando tem chave.
+	Idempotency *IdempotencyConfig
+}
+
+type Request struct {
+	Method  string
+	URL     string // absoluta ou relativa ao BaseURL
+	Query   url.Values
+	Headers map[string]string
+	Body    interface{}
+	Encoder Encoder // padrao o Encoder do client
+	// IdempotencyKey fixa a chave de idempotencia desta requisicao.
+	IdempotencyKey string
+}
+
+type Client struct {
+	config       ClientConfig
+	httpClient   *http.Client
+	streamClient *http.Client // sem timeout total; a leitura do stream e limitada pelo contexto
+	middlewares  []Middleware
+	breakers     sync.Map
+	limiters     sync.Map
+}
+
+func NewClient(config ClientConfig) *Client {
+	config.validate()
+
+	return &Client{
+		config: config,
+		httpClient: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: config.Transport,
+		},
+		streamClient: &http.Client{
+			Transport: config.Transport,
+		},
+	}
+}
+
+func (c *ClientConfig) validate() {
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+	if c.Transport == nil {
+		c.Transport = sharedTransport
+	}
+	if c.Encoder == nil {
+		c.Encoder = JSONEncoder{}
+	}
+	c.Retry.validate()
+	if c.Breaker != nil {
+		breaker := *c.Breaker
+		breaker.validate()
+		c.Breaker = &breaker
+	}
+	if c.Idempotency != nil {
+		idempotency := *c.Idempotency
+		idempotency.validate()
+		c.Idempotency = &idempotency
+	}
+}
+
+func (c *Client) Do(ctx context.Context, req Request) (*HTTPResponse, error) {
+	response, err := c.fetch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseBody interface{}
+	if err := json.Unmarshal(response.RawBody, &responseBody); err != nil {
+		responseBody = string(response.RawBody)
+	}
+	response.Body = responseBody
+
+	return response, nil
+}
+
+// fetch executa a requisicao e le o corpo inteiro sem deserializa-lo.
+func (c *Client) fetch(ctx context.Context, req Request) (*HTTPResponse, error) {
+	resp, err := c.send(ctx, c.httpClient, req, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(c.limitBody(resp))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	response := &HTTPResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		RawBody:    rawBody,
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		response.URL = resp.Request.URL.String()
+	}
+	return response, nil
+}
+
+// send executa a requisicao aplicando a politica de retry e devolve a ultima
+// resposta com o corpo ainda aberto. headerTimeout, quando positivo, limita
+// cada tentativa ate a chegada dos cabecalhos, sem contar o backoff.
+func (c *Client) send(ctx context.Context, httpClient *http.Client, req Request, headerTimeout time.Duration) (*http.Response, error) {
+	ctx = withCallRequestID(ctx)
+
+	httpReq, err := c.newRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	replayable := httpReq.Body == nil || httpReq.GetBody != nil
+	retryable := replayable && c.retrySafe(httpReq)
+	refreshed := false
+
+	for attempt, sent := 0, false; ; attempt++ {
+		attemptReq, err := rewindRequest(ctx, httpReq, sent)
+		if err != nil {
+			return nil, err
+		}
+		sent = true
+
+		if c.config.Auth != nil {
+			if err := c.config.Auth.Authenticate(ctx, attemptReq); err != nil {
+				return nil, fmt.Errorf("failed to authenticate HTTP request: %w", err)
+			}
+		}
+
+		limiter := c.limiter(attemptReq.URL.Host)
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
+			}
+		}
+
+		breaker := c.breaker(attemptReq.URL.Host)
+		var generation uint64
+		if breaker != nil {
+			if generation, err = breaker.allow(); err != nil {
+				return nil, fmt.Errorf("failed to execute HTTP request to %s: %w", attemptReq.URL.Host, err)
+			}
+		}
+
+		resp, cancelAttempt, err := do(ctx, httpClient, attemptReq, headerTimeout)
+		if breaker != nil {
+			breaker.done(generation, ctx.Err() == nil, c.config.Breaker.isFailure(resp, err))
+		}
+		if limiter != nil && resp != nil && c.config.RateLimit.Adaptive {
+			limiter.observe(resp)
+		}
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			cancelAttempt()
+			return nil, fmt.Errorf("failed to execute HTTP request: %w", context.Cause(ctx))
+		}
+
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && !refreshed && replayable {
+			if refresher, ok := c.config.Auth.(Refresher); ok {
+				refresher.Invalidate(attemptReq)
+				refreshed = true
+				drain(resp.Body)
+				cancelAttempt()
+				attempt--
+				continue
+			}
+		}
+
+		if attempt >= c.config.Retry.MaxRetries || !retryable || !c.config.Retry.shouldRetry(resp, err) {
+			if err != nil {
+				cancelAttempt()
+				return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
+			}
+			// O contexto da tentativa vale ate o corpo ser fechado.
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancelAttempt}
+			return resp, nil
+		}
+
+		delay := c.config.Retry.backoff(attempt, resp)
+		if resp != nil {
+			drain(resp.Body)
+		}
+		cancelAttempt()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("failed to execute HTTP request: %w", context.Cause(ctx))
+		case <-timer.C:
+		}
+	}
+}
+
+// do executa uma tentativa. Com headerTimeout, a tentativa e cancelada se os
+// cabecalhos nao chegarem no prazo e o erro conta como falha no breaker. A
+// funcao devolvida libera o contexto da tentativa.
+func do(ctx context.Context, httpClient *http.Client, req *http.Request, headerTimeout time.Duration) (*http.Response, context.CancelFunc, error) {
+	if headerTimeout <= 0 {
+		resp, err := httpClient.Do(req)
+		return resp, func() {}, err
+	}
+
+	attemptCtx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(headerTimeout, cancel)
+	resp, err := httpClient.Do(req.WithContext(attemptCtx))
+	if !timer.Stop() && ctx.Err() == nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		cancel()
+		return nil, func() {}, fmt.Errorf("timeout awaiting response headers after %s", headerTimeout)
+	}
+	return resp, cancel, err
+}
+
+// BreakerState informa o estado do circuit breaker do host; sem breaker
+// configurado o circuito e sempre considerado fechado.
+func (c *Client) BreakerState(host string) BreakerState {
+	breaker := c.breaker(host)
+	if breaker == nil {
+		return StateClosed
+	}
+	return breaker.State()
+}
+
+func (c *Client) breaker(host string) *Breaker {
+	if c.config.Breaker == nil {
+		return nil
+	}
+	if breaker, ok := c.breakers.Load(host); ok {
+		return breaker.(*Breaker)
+	}
+	breaker, _ := c.breakers.LoadOrStore(host, NewBreaker(host, *c.config.Breaker))
+	return breaker.(*Breaker)
+}
+
+func (c *Client) limiter(host string) *RateLimiter {
+	if c.config.RateLimit == nil {
+		return nil
+	}
+	if !c.config.RateLimit.PerHost {
+		host = ""
+	}
+	if limiter, ok := c.limiters.Load(host); ok {
+		return limiter.(*RateLimiter)
+	}
+	limiter, _ := c.limiters.LoadOrStore(host, NewRateLimiter(c.config.RateLimit.RequestsPerSecond, c.config.RateLimit.Burst))
+	return limiter.(*RateLimiter)
+}
+
+func (c *Client) newRequest(ctx context.Context, req Request) (*http.Request, error) {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	target, err := c.resolveURL(req.URL, req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	var requestBody io.Reader
+	var contentType string
+	if req.Body != nil {
+		encoder := req.Encoder
+		if encoder == nil {
+			encoder = c.config.Encoder
+		}
+		requestBody, contentType, err = encoder.Encode(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize request body: %w", err)
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, target, requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	for key, value := range c.config.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	for key, value := range req.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	if key := c.idempotencyKey(req, method); key != "" {
+		httpReq.Header.Set(c.idempotencyHeader(), key)
+	}
+
+	return httpReq, nil
+}
+
+func (c *Client) resolveURL(rawURL string, query url.Values) (string, error) {
+	target := rawURL
+	if c.config.BaseURL != "" && !strings.Contains(rawURL, "://") {
+		target = strings.TrimRight(c.config.BaseURL, "/") + "/" + strings.TrimLeft(rawURL, "/")
+	}
+
+	if len(query) == 0 {
+		return target, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	values := u.Query()
+	for key, list := range query {
+		for _, value := range list {
+			values.Add(key, value)
+		}
+	}
+	u.RawQuery = values.Encode()
+	return u.String(), nil
+}
+
+// rewindRequest prepara uma copia da requisicao para a proxima tentativa,
+// recriando o corpo a partir de GetBody quando ele ja foi enviado.
+func rewindRequest(ctx context.Context, req *http.Request, sent bool) (*http.Request, error) {
+	clone := req.Clone(ctx)
+	if !sent || req.Body == nil {
+		return clone, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("failed to retry HTTP request: body cannot be replayed")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry HTTP request: %w", err)
+	}
+	clone.Body = body
+	return clone, nil
+}
+
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func drain(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, 64<<10))
+	body.Close()
+}
@@ -0,0 +1,197 @@
+package call
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetriesServerErrors(t *testing.T) {
+	var attempts atomic.Int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		BaseURL: server.URL,
+		Retry:   RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond},
+	})
+	resp, err := client.Do(context.Background(), Request{Method: http.MethodPut, URL: "/items/1", Body: map[string]int{"n": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || attempts.Load() != 3 {
+		t.Fatalf("status=%d attempts=%d", resp.StatusCode, attempts.Load())
+	}
+	for _, body := range bodies {
+		if body != `{"n":1}` {
+			t.Fatalf("body was not replayed on retry: %q", bodies)
+		}
+	}
+	if resp.Body.(map[string]interface{})["ok"] != true {
+		t.Fatalf("decoded body = %v", resp.Body)
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{Retry: RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}})
+	resp, err := client.Do(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || attempts.Load() != 3 {
+		t.Fatalf("status=%d attempts=%d, want 503 after 3 attempts", resp.StatusCode, attempts.Load())
+	}
+}
+
+func TestClientDoesNotRetryClientErrorsOrUnsafeMethods(t *testing.T) {
+	var attempts atomic.Int32
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{Retry: RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond}})
+
+	client.Do(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+	if attempts.Load() != 1 {
+		t.Fatalf("4xx attempts = %d, want 1", attempts.Load())
+	}
+
+	// POST sem chave de idempotencia nao e repetido.
+	attempts.Store(0)
+	status = http.StatusInternalServerError
+	client.Do(context.Background(), Request{Method: http.MethodPost, URL: server.URL, Body: map[string]int{}})
+	if attempts.Load() != 1 {
+		t.Fatalf("POST attempts = %d, want 1", attempts.Load())
+	}
+}
+
+func TestClientRespectsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{Retry: RetryPolicy{MaxRetries: 5}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Do(ctx, Request{Method: http.MethodGet, URL: server.URL})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("client waited for Retry-After instead of the context deadline")
+	}
+}
+
+func TestClientHeadersAndQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Client") + "|" + r.Header.Get("X-Request") + "|" + r.URL.RawQuery))
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{BaseURL: server.URL + "/api", Headers: map[string]string{"X-Client": "a", "X-Request": "client"}})
+	resp, err := client.Do(context.Background(), Request{
+		Method:  http.MethodGet,
+		URL:     "users",
+		Query:   map[string][]string{"q": {"x y"}},
+		Headers: map[string]string{"X-Request": "request"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Body != "a|request|q=x+y" {
+		t.Fatalf("body = %v", resp.Body)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	policy.validate()
+
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := policy.backoff(attempt, nil)
+		limit := policy.BaseDelay << attempt
+		if limit > policy.MaxDelay || limit <= 0 {
+			limit = policy.MaxDelay
+		}
+		if delay <= 0 || delay > limit {
+			t.Fatalf("attempt %d: delay %v outside (0, %v]", attempt, delay, limit)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"7"}}}
+	if delay := policy.backoff(0, resp); delay != 7*time.Second {
+		t.Fatalf("Retry-After delay = %v, want 7s", delay)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if wait, ok := retryAfter("3"); !ok || wait != 3*time.Second {
+		t.Fatalf("seconds: %v %v", wait, ok)
+	}
+	date := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if wait, ok := retryAfter(date); !ok || wait <= 0 || wait > 10*time.Second {
+		t.Fatalf("http date: %v %v", wait, ok)
+	}
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	if wait, ok := retryAfter(past); !ok || wait != 0 {
+		t.Fatalf("past date: %v %v", wait, ok)
+	}
+	for _, value := range []string{"", "-1", "soon"} {
+		if _, ok := retryAfter(value); ok {
+			t.Fatalf("retryAfter(%q) should be invalid", value)
+		}
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	var policy RetryPolicy
+	cases := []struct {
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{nil, errors.New("connection reset"), true},
+		{&http.Response{StatusCode: http.StatusTooManyRequests}, nil, true},
+		{&http.Response{StatusCode: http.StatusInternalServerError}, nil, true},
+		{&http.Response{StatusCode: http.StatusNotFound}, nil, false},
+		{&http.Response{StatusCode: http.StatusOK}, nil, false},
+	}
+	for _, c := range cases {
+		if got := policy.shouldRetry(c.resp, c.err); got != c.want {
+			t.Fatalf("shouldRetry(%v, %v) = %v, want %v", c.resp, c.err, got, c.want)
+		}
+	}
+
+	custom := RetryPolicy{RetryOn: func(resp *http.Response, err error) bool { return resp.StatusCode == http.StatusConflict }}
+	if !custom.shouldRetry(&http.Response{StatusCode: http.StatusConflict}, nil) {
+		t.Fatal("RetryOn should replace the default rule")
+	}
+}
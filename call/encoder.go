@@ -0,0 +1,176 @@
+package call
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// Encoder serializa o corpo da requisicao e informa o Content-Type
+// correspondente. Corpos devolvidos como *bytes.Reader, *bytes.Buffer ou
+// *strings.Reader podem ser reenviados em caso de retry.
+type Encoder interface {
+	Encode(body interface{}) (io.Reader, string, error)
+}
+
+type EncoderFunc func(body interface{}) (io.Reader, string, error)
+
+func (f EncoderFunc) Encode(body interface{}) (io.Reader, string, error) {
+	return f(body)
+}
+
+type JSONEncoder struct{}
+
+func (JSONEncoder) Encode(body interface{}) (io.Reader, string, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", err
+	}
+	return bytes.NewReader(data), "application/json", nil
+}
+
+// FormEncoder aceita url.Values, map[string]string ou map[string][]string.
+type FormEncoder struct{}
+
+func (FormEncoder) Encode(body interface{}) (io.Reader, string, error) {
+	values := url.Values{}
+
+	switch v := body.(type) {
+	case url.Values:
+		values = v
+	case map[string][]string:
+		values = url.Values(v)
+	case map[string]string:
+		for key, value := range v {
+			values.Set(key, value)
+		}
+	default:
+		return nil, "", fmt.Errorf("form encoder: unsupported body type %T", body)
+	}
+
+	return strings.NewReader(values.Encode()), "application/x-www-form-urlencoded", nil
+}
+
+type XMLEncoder struct{}
+
+func (XMLEncoder) Encode(body interface{}) (io.Reader, string, error) {
+	data, err := xml.Marshal(body)
+	if err != nil {
+		return nil, "", err
+	}
+	return bytes.NewReader(append([]byte(xml.Header), data...)), "application/xml; charset=utf-8", nil
+}
+
+type MultipartFile struct {
+	Field       string
+	FileName    string
+	ContentType string // padrao application/octet-stream
+	Content     io.Reader
+}
+
+type MultipartForm struct {
+	Fields map[string]string
+	Files  []MultipartFile
+}
+
+// MultipartEncoder aceita MultipartForm ou *MultipartForm. O formulario e
+// montado em memoria para permitir retry; para arquivos grandes use
+// StreamEncoder com um io.Pipe.
+type MultipartEncoder struct{}
+
+func (MultipartEncoder) Encode(body interface{}) (io.Reader, string, error) {
+	var form MultipartForm
+	switch v := body.(type) {
+	case MultipartForm:
+		form = v
+	case *MultipartForm:
+		form = *v
+	default:
+		return nil, "", fmt.Errorf("multipart encoder: unsupported body type %T", body)
+	}
+
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+
+	for key, value := range form.Fields {
+		if err := writer.WriteField(key, value); err != nil {
+			return nil, "", err
+		}
+	}
+
+	for _, file := range form.Files {
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(file.Field), escapeQuotes(file.FileName)))
+		header.Set("Content-Type", contentType)
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := io.Copy(part, file.Content); err != nil {
+			return nil, "", fmt.Errorf("multipart encoder: failed to read file %s: %w", file.FileName, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf, writer.FormDataContentType(), nil
+}
+
+// RawEncoder envia []byte ou string sem transformacao.
+type RawEncoder struct {
+	ContentType string // padrao application/octet-stream
+}
+
+func (e RawEncoder) Encode(body interface{}) (io.Reader, string, error) {
+	contentType := e.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	switch v := body.(type) {
+	case []byte:
+		return bytes.NewReader(v), contentType, nil
+	case string:
+		return strings.NewReader(v), contentType, nil
+	}
+	return nil, "", fmt.Errorf("raw encoder: unsupported body type %T", body)
+}
+
+// StreamEncoder envia um io.Reader diretamente, sem bufferizar. Readers que
+// nao possam ser rebobinados desativam o retry da requisicao.
+type StreamEncoder struct {
+	ContentType string // padrao application/octet-stream
+}
+
+func (e StreamEncoder) Encode(body interface{}) (io.Reader, string, error) {
+	contentType := e.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	reader, ok := body.(io.Reader)
+	if !ok {
+		return nil, "", fmt.Errorf("stream encoder: unsupported body type %T", body)
+	}
+	return reader, contentType, nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
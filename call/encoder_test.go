@@ -0,0 +1,140 @@
+package call
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func readEncoded(t *testing.T, encoder Encoder, body interface{}) (string, string) {
+	t.Helper()
+	reader, contentType, err := encoder.Encode(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), contentType
+}
+
+func TestEncoders(t *testing.T) {
+	type item struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	tests := []struct {
+		name        string
+		encoder     Encoder
+		body        interface{}
+		want        string
+		contentType string
+	}{
+		{"json", JSONEncoder{}, item{Name: "a"}, `{"name":"a"}`, "application/json"},
+		{"form values", FormEncoder{}, url.Values{"a": {"1", "2"}}, "a=1&a=2", "application/x-www-form-urlencoded"},
+		{"form map", FormEncoder{}, map[string]string{"q": "a b"}, "q=a+b", "application/x-www-form-urlencoded"},
+		{"xml", XMLEncoder{}, item{Name: "a"}, `<?xml version="1.0" encoding="UTF-8"?>` + "\n<item><name>a</name></item>", "application/xml; charset=utf-8"},
+		{"raw bytes", RawEncoder{}, []byte{0x01, 0x02}, "\x01\x02", "application/octet-stream"},
+		{"raw string", RawEncoder{ContentType: "text/csv"}, "a,b", "a,b", "text/csv"},
+		{"stream", StreamEncoder{ContentType: "text/plain"}, strings.NewReader("data"), "data", "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, contentType := readEncoded(t, tt.encoder, tt.body)
+			if got != tt.want || contentType != tt.contentType {
+				t.Fatalf("got %q (%s), want %q (%s)", got, contentType, tt.want, tt.contentType)
+			}
+		})
+	}
+}
+
+func TestEncodersRejectUnsupportedBodies(t *testing.T) {
+	for name, encoder := range map[string]Encoder{
+		"form":      FormEncoder{},
+		"multipart": MultipartEncoder{},
+		"raw":       RawEncoder{},
+		"stream":    StreamEncoder{},
+	} {
+		if _, _, err := encoder.Encode(42); err == nil {
+			t.Errorf("%s: expected error for int body", name)
+		}
+	}
+}
+
+func TestMultipartEncoder(t *testing.T) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm: %v", err)
+			return
+		}
+		if r.FormValue("name") != "report" {
+			t.Errorf("field name = %q", r.FormValue("name"))
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("FormFile: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != `a"b.csv` || string(data) != "1,2" || header.Header.Get("Content-Type") != "application/octet-stream" {
+			t.Errorf("file = %q %q %q", header.Filename, data, header.Header.Get("Content-Type"))
+		}
+		if received.Load() == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{Retry: RetryPolicy{MaxRetries: 1, BaseDelay: 1}})
+	_, err := client.Do(context.Background(), Request{
+		Method:  http.MethodPost,
+		URL:     server.URL,
+		Encoder: MultipartEncoder{},
+		Body: &MultipartForm{
+			Fields: map[string]string{"name": "report"},
+			Files:  []MultipartFile{{Field: "file", FileName: `a"b.csv`, Content: bytes.NewReader([]byte("1,2"))}},
+		},
+		IdempotencyKey: "upload-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Load() != 2 {
+		t.Fatalf("server calls = %d, want multipart body replayed on retry", received.Load())
+	}
+}
+
+func TestRequestEncoderOverridesClientEncoder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		w.Write([]byte(`{"type":"` + r.Header.Get("Content-Type") + `","body":"` + string(data) + `"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{Encoder: FormEncoder{}})
+	resp, err := client.Do(context.Background(), Request{Method: http.MethodPost, URL: server.URL, Body: map[string]string{"a": "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Body.(map[string]interface{}); got["type"] != "application/x-www-form-urlencoded" || got["body"] != "a=1" {
+		t.Fatalf("client encoder: %v", got)
+	}
+
+	resp, err = client.Do(context.Background(), Request{Method: http.MethodPost, URL: server.URL, Body: "x", Encoder: RawEncoder{ContentType: "text/plain"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Body.(map[string]interface{}); got["type"] != "text/plain" || got["body"] != "x" {
+		t.Fatalf("request encoder: %v", got)
+	}
+}
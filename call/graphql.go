@@ -0,0 +1,181 @@
+package call
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type GraphQLClient struct {
+	client   *Client
+	endpoint string
+}
+
+// NewGraphQLClient cria um client GraphQL sobre o client HTTP informado
+// (nil usa o client padrao). endpoint pode ser relativo ao BaseURL.
+func NewGraphQLClient(client *Client, endpoint string) *GraphQLClient {
+	return &GraphQLClient{client: client, endpoint: endpoint}
+}
+
+type GraphQLRequest struct {
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+	Headers       map[string]string
+	// Persisted envia apenas o hash SHA-256 da query (Automatic Persisted
+	// Queries); se o servidor nao conhecer o hash, a query completa e enviada.
+	Persisted bool
+}
+
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Locations  []GraphQLLocation      `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, segment := range e.Path {
+		path[i] = fmt.Sprint(segment)
+	}
+	return fmt.Sprintf("%s (path: %s)", e.Message, strings.Join(path, "."))
+}
+
+// Code devolve extensions.code, usado pela maioria dos servidores para
+// classificar o erro.
+func (e GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// GraphQLErrors e o array errors da resposta. Pode ser devolvido junto com
+// dados parciais.
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+type graphQLBody struct {
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// GraphQL executa uma query ou mutation e decodifica data em T. Quando a
+// resposta traz errors, o retorno e GraphQLErrors junto com os dados que
+// puderam ser decodificados.
+func GraphQL[T any](ctx context.Context, g *GraphQLClient, req GraphQLRequest) (T, error) {
+	var result T
+
+	body := graphQLBody{
+		Query:         req.Query,
+		OperationName: req.OperationName,
+		Variables:     req.Variables,
+	}
+	if req.Persisted {
+		hash := sha256.Sum256([]byte(req.Query))
+		body.Query = ""
+		body.Extensions = map[string]interface{}{
+			"persistedQuery": map[string]interface{}{
+				"version":    1,
+				"sha256Hash": hex.EncodeToString(hash[:]),
+			},
+		}
+	}
+
+	response, err := g.post(ctx, req, body)
+	if err != nil {
+		return result, err
+	}
+
+	if req.Persisted && persistedQueryMissing(response.Errors) {
+		body.Query = req.Query
+		if response, err = g.post(ctx, req, body); err != nil {
+			return result, err
+		}
+	}
+
+	if len(response.Data) > 0 && string(response.Data) != "null" {
+		if err := json.Unmarshal(response.Data, &result); err != nil {
+			return result, fmt.Errorf("graphql: failed to decode data: %w", err)
+		}
+	}
+	if len(response.Errors) > 0 {
+		return result, response.Errors
+	}
+	return result, nil
+}
+
+func (g *GraphQLClient) post(ctx context.Context, req GraphQLRequest, body graphQLBody) (*graphQLResponse, error) {
+	client := g.client
+	if client == nil {
+		client = DefaultClient()
+	}
+
+	headers := map[string]string{"Accept": "application/graphql-response+json, application/json"}
+	for key, value := range req.Headers {
+		headers[key] = value
+	}
+
+	response, err := client.fetch(ctx, Request{
+		Method:  http.MethodPost,
+		URL:     g.endpoint,
+		Headers: headers,
+		Body:    body,
+		Encoder: JSONEncoder{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded graphQLResponse
+	decodeErr := json.Unmarshal(response.RawBody, &decoded)
+
+	// Servidores GraphQL podem responder 4xx com o array errors preenchido.
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		if decodeErr == nil && len(decoded.Errors) > 0 {
+			return &decoded, nil
+		}
+		return nil, &ResponseError{StatusCode: response.StatusCode, RawBody: response.RawBody}
+	}
+	if decodeErr != nil {
+		return nil, &ResponseError{StatusCode: response.StatusCode, RawBody: response.RawBody, Err: decodeErr}
+	}
+	return &decoded, nil
+}
+
+func persistedQueryMissing(errors GraphQLErrors) bool {
+	for _, err := range errors {
+		switch {
+		case err.Code() == "PERSISTED_QUERY_NOT_FOUND", err.Code() == "PERSISTED_QUERY_NOT_SUPPORTED":
+			return true
+		case err.Message == "PersistedQueryNotFound", err.Message == "PersistedQueryNotSupported":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package call
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGraphQLQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body graphQLBody
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Query == "" || body.OperationName != "GetUser" || body.Variables["id"] != "7" {
+			t.Errorf("body = %+v", body)
+		}
+		w.Write([]byte(`{"data":{"user":{"name":"Ana"}}}`))
+	}))
+	defer server.Close()
+
+	result, err := GraphQL[struct {
+		User struct{ Name string } `json:"user"`
+	}](context.Background(), NewGraphQLClient(nil, server.URL), GraphQLRequest{
+		Query:         `query GetUser($id: ID!) { user(id: $id) { name } }`,
+		OperationName: "GetUser",
+		Variables:     map[string]interface{}{"id": "7"},
+	})
+	if err != nil || result.User.Name != "Ana" {
+		t.Fatalf("result = %+v, err = %v", result, err)
+	}
+}
+
+func TestGraphQLPartialDataAndErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"a":1,"b":null},"errors":[{"message":"boom","path":["b",0],"extensions":{"code":"INTERNAL"}}]}`))
+	}))
+	defer server.Close()
+
+	result, err := GraphQL[map[string]interface{}](context.Background(), NewGraphQLClient(nil, server.URL), GraphQLRequest{Query: "{ a b }"})
+	var gqlErrs GraphQLErrors
+	if !errors.As(err, &gqlErrs) || len(gqlErrs) != 1 || gqlErrs[0].Code() != "INTERNAL" {
+		t.Fatalf("err = %v, want GraphQLErrors", err)
+	}
+	if err.Error() != "graphql: boom (path: b.0)" {
+		t.Fatalf("message = %q", err.Error())
+	}
+	if result["a"] != float64(1) {
+		t.Fatalf("partial data = %v", result)
+	}
+}
+
+func TestGraphQLHTTPErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"message":"unauthenticated"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`<html>bad gateway</html>`))
+	}))
+	defer server.Close()
+	g := NewGraphQLClient(nil, server.URL)
+
+	_, err := GraphQL[map[string]interface{}](context.Background(), g, GraphQLRequest{Query: "{ a }"})
+	var gqlErrs GraphQLErrors
+	if !errors.As(err, &gqlErrs) || gqlErrs[0].Message != "unauthenticated" {
+		t.Fatalf("err = %v, want GraphQLErrors from 401 body", err)
+	}
+
+	_, err = GraphQL[map[string]interface{}](context.Background(), g, GraphQLRequest{Query: "{ a }", Headers: map[string]string{"Authorization": "x"}})
+	var responseErr *ResponseError
+	if !errors.As(err, &responseErr) || responseErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("err = %v, want ResponseError 502", err)
+	}
+}
+
+func TestGraphQLPersistedQueryFallback(t *testing.T) {
+	var requests []graphQLBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body graphQLBody
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+		if body.Query == "" {
+			w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer server.Close()
+
+	result, err := GraphQL[map[string]bool](context.Background(), NewGraphQLClient(nil, server.URL), GraphQLRequest{Query: "{ ok }", Persisted: true})
+	if err != nil || !result["ok"] {
+		t.Fatalf("result = %v, err = %v", result, err)
+	}
+	if len(requests) != 2 || requests[0].Query != "" || requests[1].Query != "{ ok }" {
+		t.Fatalf("requests = %+v, want hash-only then full query", requests)
+	}
+	sum := sha256.Sum256([]byte("{ ok }"))
+	if hash := requests[0].Extensions["persistedQuery"].(map[string]interface{})["sha256Hash"]; hash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("sha256Hash = %v", hash)
+	}
+}
@@ -1,18 +1,38 @@
 package call
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
+	"context"
 	"net/http"
+	"sync/atomic"
 )
 
 type HTTPResponse struct {
 	StatusCode int
+	Header     http.Header
 	Body       interface{}
 	RawBody    []byte
 	Error      error
+	// URL e o endereco efetivamente requisitado, apos BaseURL, query e
+	// redirects. Serve de base para links relativos da resposta.
+	URL string
+}
+
+var defaultClient atomic.Pointer[Client]
+
+func init() {
+	defaultClient.Store(NewClient(ClientConfig{}))
+}
+
+// DefaultClient devolve o client usado por MakeHTTPRequest e pelas funcoes
+// que recebem client nil.
+func DefaultClient() *Client {
+	return defaultClient.Load()
+}
+
+// SetDefaultClient troca o client padrao e devolve o anterior, permitindo por
+// exemplo usar um Cassette nos testes de quem chama MakeHTTPRequest.
+func SetDefaultClient(client *Client) *Client {
+	return defaultClient.Swap(client)
 }
 
 func MakeHTTPRequest(
@@ -21,50 +41,10 @@ func MakeHTTPRequest(
 	headers map[string]string,
 	body interface{},
 ) (*HTTPResponse, error) {
-	var requestBody io.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to serialize request body: %w", err)
-		}
-		requestBody = bytes.NewBuffer(jsonBody)
-	}
-
-	req, err := http.NewRequest(method, url, requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	for key, value := range headers {
-		req.Header.Set(key, value)
-	}
-
-	if body != nil && (method == http.MethodPost || method == http.MethodPut) {
-		req.Header.Set("Content-Type", "application/json")
-	}
-
-	client := &http.Client{}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	rawBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var responseBody interface{}
-	if err := json.Unmarshal(rawBody, &responseBody); err != nil {
-		responseBody = string(rawBody)
-	}
-
-	return &HTTPResponse{
-		StatusCode: resp.StatusCode,
-		Body:       responseBody,
-		RawBody:    rawBody,
-		Error:      nil,
-	}, nil
+	return DefaultClient().Do(context.Background(), Request{
+		Method:  method,
+		URL:     url,
+		Headers: headers,
+		Body:    body,
+	})
 }
@@ -0,0 +1,63 @@
+package call
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type IdempotencyConfig struct {
+	Header   string        // padrao Idempotency-Key
+	Methods  []string      // metodos que recebem chave automatica; padrao POST e PATCH
+	Generate func() string // padrao UUID v4
+}
+
+func (c *IdempotencyConfig) validate() {
+	if c.Header == "" {
+		c.Header = "Idempotency-Key"
+	}
+	if len(c.Methods) == 0 {
+		c.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	if c.Generate == nil {
+		c.Generate = uuid.NewString
+	}
+}
+
+// idempotencyHeader devolve o cabecalho que identifica requisicoes seguras
+// para retry, mesmo sem IdempotencyConfig no client.
+func (c *Client) idempotencyHeader() string {
+	if c.config.Idempotency == nil {
+		return "Idempotency-Key"
+	}
+	return c.config.Idempotency.Header
+}
+
+// idempotencyKey define a chave da requisicao logica. Ela e gerada uma unica
+// vez por chamada e reaproveitada em todas as tentativas.
+func (c *Client) idempotencyKey(req Request, method string) string {
+	if req.IdempotencyKey != "" {
+		return req.IdempotencyKey
+	}
+	if c.config.Idempotency == nil || hasHeader(req.Headers, c.config.Idempotency.Header) {
+		return ""
+	}
+
+	for _, m := range c.config.Idempotency.Methods {
+		if strings.EqualFold(m, method) {
+			return c.config.Idempotency.Generate()
+		}
+	}
+	return ""
+}
+
+// retrySafe indica se a requisicao pode ser repetida automaticamente: metodos
+// idempotentes sempre; os demais apenas com chave de idempotencia.
+func (c *Client) retrySafe(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(c.idempotencyHeader()) != ""
+}
@@ -0,0 +1,79 @@
+package call
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestIdempotencyKeyReusedAcrossRetries(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		attempt := len(keys)
+		mu.Unlock()
+		if attempt < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{Retry: RetryPolicy{MaxRetries: 3, BaseDelay: 1}, Idempotency: &IdempotencyConfig{}})
+	resp, err := client.Do(context.Background(), Request{Method: http.MethodPost, URL: server.URL, Body: map[string]int{"n": 1}})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %v, err = %v", resp, err)
+	}
+	if len(keys) != 3 || keys[0] == "" || keys[0] != keys[1] || keys[1] != keys[2] {
+		t.Fatalf("keys = %v, want the same key on every attempt", keys)
+	}
+}
+
+func TestIdempotencyKeySources(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *IdempotencyConfig
+		req    Request
+		want   string
+	}{
+		{"explicit key without config", nil, Request{Method: http.MethodPost, IdempotencyKey: "k1"}, "k1"},
+		{"generated for POST", &IdempotencyConfig{Generate: func() string { return "gen" }}, Request{Method: http.MethodPost}, "gen"},
+		{"not generated for GET", &IdempotencyConfig{Generate: func() string { return "gen" }}, Request{Method: http.MethodGet}, ""},
+		{"custom methods", &IdempotencyConfig{Methods: []string{"delete"}, Generate: func() string { return "gen" }}, Request{Method: http.MethodDelete}, "gen"},
+		{"caller header kept", &IdempotencyConfig{Generate: func() string { return "gen" }}, Request{Method: http.MethodPost, Headers: map[string]string{"idempotency-key": "own"}}, "own"},
+		{"no config", nil, Request{Method: http.MethodPost}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(ClientConfig{Idempotency: tt.config})
+			req, err := client.newRequest(context.Background(), tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.Header.Get("Idempotency-Key"); got != tt.want {
+				t.Fatalf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetrySafe(t *testing.T) {
+	client := NewClient(ClientConfig{Idempotency: &IdempotencyConfig{Header: "X-Request-Key"}})
+
+	for method, want := range map[string]bool{http.MethodGet: true, http.MethodPut: true, http.MethodDelete: true, http.MethodPost: false, http.MethodPatch: false} {
+		req, _ := http.NewRequest(method, "http://example.com", nil)
+		if got := client.retrySafe(req); got != want {
+			t.Errorf("%s retrySafe = %v, want %v", method, got, want)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	req.Header.Set("X-Request-Key", "k")
+	if !client.retrySafe(req) {
+		t.Error("POST with idempotency header should be retry safe")
+	}
+}
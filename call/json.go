@@ -0,0 +1,91 @@
+package call
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ResponseError e devolvido pelas funcoes tipadas quando o status nao e 2xx
+// ou quando o corpo nao pode ser decodificado no tipo pedido.
+type ResponseError struct {
+	StatusCode int
+	RawBody    []byte
+	Err        error
+}
+
+func (e *ResponseError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("failed to decode response body (status %d): %v", e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, truncate(e.RawBody, 512))
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// DoJSON executa a requisicao e decodifica o corpo JSON da resposta em T.
+// Com client nil e usado o client padrao de MakeHTTPRequest.
+func DoJSON[T any](ctx context.Context, client *Client, req Request) (T, *HTTPResponse, error) {
+	var result T
+
+	if client == nil {
+		client = DefaultClient()
+	}
+	if !hasHeader(req.Headers, "Accept") {
+		headers := map[string]string{"Accept": "application/json"}
+		for key, value := range req.Headers {
+			headers[key] = value
+		}
+		req.Headers = headers
+	}
+
+	response, err := client.fetch(ctx, req)
+	if err != nil {
+		return result, nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return result, response, &ResponseError{StatusCode: response.StatusCode, RawBody: response.RawBody}
+	}
+
+	if len(response.RawBody) == 0 {
+		return result, response, nil
+	}
+
+	if err := json.Unmarshal(response.RawBody, &result); err != nil {
+		return result, response, &ResponseError{StatusCode: response.StatusCode, RawBody: response.RawBody, Err: err}
+	}
+	response.Body = result
+
+	return result, response, nil
+}
+
+func Get[T any](ctx context.Context, client *Client, url string) (T, *HTTPResponse, error) {
+	return DoJSON[T](ctx, client, Request{Method: http.MethodGet, URL: url})
+}
+
+func Post[T any](ctx context.Context, client *Client, url string, body interface{}) (T, *HTTPResponse, error) {
+	return DoJSON[T](ctx, client, Request{Method: http.MethodPost, URL: url, Body: body})
+}
+
+func Put[T any](ctx context.Context, client *Client, url string, body interface{}) (T, *HTTPResponse, error) {
+	return DoJSON[T](ctx, client, Request{Method: http.MethodPut, URL: url, Body: body})
+}
+
+func Patch[T any](ctx context.Context, client *Client, url string, body interface{}) (T, *HTTPResponse, error) {
+	return DoJSON[T](ctx, client, Request{Method: http.MethodPatch, URL: url, Body: body})
+}
+
+func Delete[T any](ctx context.Context, client *Client, url string) (T, *HTTPResponse, error) {
+	return DoJSON[T](ctx, client, Request{Method: http.MethodDelete, URL: url})
+}
+
+func truncate(body []byte, limit int) string {
+	if len(body) <= limit {
+		return string(body)
+	}
+	return string(body[:limit]) + "..."
+}
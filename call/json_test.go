@@ -0,0 +1,60 @@
+package call
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestDoJSONDecodesIntoType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/json" {
+			t.Errorf("Accept = %q", r.Header.Get("Accept"))
+		}
+		w.Write([]byte(`{"id":7,"name":"Ana"}`))
+	}))
+	defer server.Close()
+
+	got, resp, err := Get[user](context.Background(), NewClient(ClientConfig{}), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (user{ID: 7, Name: "Ana"}) || resp.StatusCode != http.StatusOK {
+		t.Fatalf("got %+v, status %d", got, resp.StatusCode)
+	}
+}
+
+func TestDoJSONErrors(t *testing.T) {
+	body := `{"error":"not found"}`
+	status := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	client := NewClient(ClientConfig{})
+
+	_, _, err := Get[user](context.Background(), client, server.URL)
+	var responseErr *ResponseError
+	if !errors.As(err, &responseErr) || responseErr.StatusCode != http.StatusNotFound || string(responseErr.RawBody) != body {
+		t.Fatalf("err = %v, want ResponseError with status and body", err)
+	}
+
+	status, body = http.StatusOK, `not json`
+	_, _, err = Get[user](context.Background(), client, server.URL)
+	if !errors.As(err, &responseErr) || responseErr.Err == nil {
+		t.Fatalf("err = %v, want decode ResponseError", err)
+	}
+
+	status, body = http.StatusNoContent, ``
+	if _, _, err = Delete[user](context.Background(), client, server.URL); err != nil {
+		t.Fatalf("empty body err = %v", err)
+	}
+}
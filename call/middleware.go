@@ -0,0 +1,249 @@
+package call
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Middleware envolve o RoundTripper do client e e executado a cada
+// tentativa de envio, inclusive nos retries.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Use adiciona middlewares ao client. O primeiro registrado e o mais externo.
+// Deve ser chamado antes de o client comecar a ser usado.
+func (c *Client) Use(middlewares ...Middleware) {
+	c.middlewares = append(c.middlewares, middlewares...)
+
+	transport := c.config.Transport
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		transport = c.middlewares[i](transport)
+	}
+	c.httpClient.Transport = transport
+	c.streamClient.Transport = transport
+}
+
+type requestIDKey struct{}
+
+// callRequestIDKey guarda o ID gerado uma unica vez por chamada do Client,
+// para que todas as tentativas da mesma requisicao logica usem o mesmo ID.
+type callRequestIDKey struct{}
+
+func withCallRequestID(ctx context.Context) context.Context {
+	if RequestIDFromContext(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, callRequestIDKey{}, uuid.NewString())
+}
+
+// WithRequestID associa um ID de correlacao ao contexto, propagado pelo
+// middleware RequestID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID envia o ID de correlacao do contexto no cabecalho informado
+// (padrao X-Request-Id). Sem ID no contexto e sem o cabecalho na requisicao,
+// usa o UUID gerado pelo Client para a chamada, o mesmo em todos os retries.
+func RequestID(header string) Middleware {
+	if header == "" {
+		header = "X-Request-Id"
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			id := RequestIDFromContext(req.Context())
+			if id == "" && req.Header.Get(header) != "" {
+				return next.RoundTrip(req)
+			}
+			if id == "" {
+				id, _ = req.Context().Value(callRequestIDKey{}).(string)
+			}
+			if id == "" {
+				id = uuid.NewString()
+			}
+
+			// O RoundTripper nao deve alterar a requisicao recebida.
+			clone := req.Clone(req.Context())
+			clone.Header.Set(header, id)
+			return next.RoundTrip(clone)
+		})
+	}
+}
+
+type LogConfig struct {
+	LogBodies     bool
+	MaxBodyLog    int      // padrao 4096 bytes de cada corpo
+	RedactHeaders []string // padrao Authorization, Proxy-Authorization, Cookie, Set-Cookie, X-Api-Key
+	RedactFields  []string // campos JSON/form mascarados nos corpos, ex.: password, token
+}
+
+const redacted = "[REDACTED]"
+
+// Logging registra cada tentativa com slog, mascarando cabecalhos e campos
+// sensiveis. Os corpos sao lidos apenas ate MaxBodyLog e devolvidos intactos.
+func Logging(logger *slog.Logger, config LogConfig) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if config.MaxBodyLog <= 0 {
+		config.MaxBodyLog = 4096
+	}
+	if config.RedactHeaders == nil {
+		config.RedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	}
+	fields := fieldRedactor(config.RedactFields)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("url", req.URL.Redacted()),
+				slog.Any("request_headers", redactHeaders(req.Header, config.RedactHeaders)),
+			}
+			if config.LogBodies && req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					prefix, _ := io.ReadAll(io.LimitReader(body, int64(config.MaxBodyLog)))
+					body.Close()
+					attrs = append(attrs, slog.String("request_body", fields(string(prefix))))
+				}
+			}
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+
+			if err != nil {
+				logger.LogAttrs(req.Context(), slog.LevelError, "http request failed", append(attrs, slog.String("error", err.Error()))...)
+				return resp, err
+			}
+
+			attrs = append(attrs,
+				slog.Int("status", resp.StatusCode),
+				slog.Any("response_headers", redactHeaders(resp.Header, config.RedactHeaders)),
+			)
+			if config.LogBodies {
+				prefix, readErr := io.ReadAll(io.LimitReader(resp.Body, int64(config.MaxBodyLog)))
+				resp.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(prefix), errorOnlyReader(resp.Body, readErr)), resp.Body}
+				attrs = append(attrs, slog.String("response_body", fields(string(prefix))))
+			}
+
+			level := slog.LevelInfo
+			if resp.StatusCode >= 500 {
+				level = slog.LevelError
+			} else if resp.StatusCode >= 400 {
+				level = slog.LevelWarn
+			}
+			logger.LogAttrs(req.Context(), level, "http request", attrs...)
+			return resp, nil
+		})
+	}
+}
+
+type RequestMetrics struct {
+	Method     string
+	Host       string
+	Path       string
+	StatusCode int // 0 quando houve erro de rede
+	Duration   time.Duration
+	Err        error
+}
+
+type MetricsRecorder interface {
+	ObserveHTTPRequest(metrics RequestMetrics)
+}
+
+type MetricsRecorderFunc func(metrics RequestMetrics)
+
+func (f MetricsRecorderFunc) ObserveHTTPRequest(metrics RequestMetrics) {
+	f(metrics)
+}
+
+// Metrics informa latencia e status de cada tentativa ao recorder, que pode
+// encaminhar para Prometheus, OpenTelemetry, StatsD etc.
+func Metrics(recorder MetricsRecorder) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+
+			metrics := RequestMetrics{
+				Method:   req.Method,
+				Host:     req.URL.Host,
+				Path:     req.URL.Path,
+				Duration: time.Since(start),
+				Err:      err,
+			}
+			if resp != nil {
+				metrics.StatusCode = resp.StatusCode
+			}
+			recorder.ObserveHTTPRequest(metrics)
+
+			return resp, err
+		})
+	}
+}
+
+func redactHeaders(header http.Header, names []string) map[string]string {
+	result := make(map[string]string, len(header))
+	for key, values := range header {
+		value := strings.Join(values, ", ")
+		for _, name := range names {
+			if strings.EqualFold(key, name) {
+				value = redacted
+				break
+			}
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// fieldRedactor mascara valores de campos JSON ("campo": valor) e de
+// formularios (campo=valor). Funciona tambem em corpos truncados.
+func fieldRedactor(names []string) func(string) string {
+	if len(names) == 0 {
+		return func(body string) string { return body }
+	}
+
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	group := strings.Join(quoted, "|")
+	jsonPattern := regexp.MustCompile(`("(?i:` + group + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	formPattern := regexp.MustCompile(`((?:^|&)(?i:` + group + `)=)[^&]*`)
+
+	return func(body string) string {
+		body = jsonPattern.ReplaceAllString(body, `${1}"`+redacted+`"`)
+		return formPattern.ReplaceAllString(body, "${1}"+redacted)
+	}
+}
+
+func errorOnlyReader(r io.Reader, err error) io.Reader {
+	if err != nil {
+		return errorReader{err}
+	}
+	return r
+}
@@ -0,0 +1,126 @@
+package call
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequestIDStableAcrossRetries(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ids = append(ids, r.Header.Get("X-Request-Id"))
+		attempt := len(ids)
+		mu.Unlock()
+		if attempt < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{Retry: RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond}})
+	client.Use(RequestID(""))
+
+	resp, err := client.Do(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Do() = %v, %v", resp, err)
+	}
+	if len(ids) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(ids))
+	}
+	for _, id := range ids {
+		if id == "" || id != ids[0] {
+			t.Fatalf("request ids = %v, want the same id on every attempt", ids)
+		}
+	}
+
+	// Uma nova chamada recebe um novo ID.
+	ids = nil
+	client.Do(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+	if len(ids) == 0 || ids[0] == "" {
+		t.Fatal("second call sent no request id")
+	}
+}
+
+func TestRequestIDPrecedence(t *testing.T) {
+	var got string
+	next := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		got = req.Header.Get("X-Correlation-Id")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+	transport := RequestID("X-Correlation-Id")(next)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req = req.WithContext(WithRequestID(req.Context(), "from-context"))
+	transport.RoundTrip(req)
+	if got != "from-context" {
+		t.Fatalf("header = %q, want id from context", got)
+	}
+	if req.Header.Get("X-Correlation-Id") != "" {
+		t.Fatal("middleware mutated the incoming request")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("X-Correlation-Id", "from-caller")
+	transport.RoundTrip(req)
+	if got != "from-caller" {
+		t.Fatalf("header = %q, want caller header kept", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	transport.RoundTrip(req)
+	if got == "" {
+		t.Fatal("no id generated outside a Client call")
+	}
+}
+
+func TestLoggingRedactsHeadersAndFields(t *testing.T) {
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	next := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+	})
+	transport := Logging(logger, LogConfig{LogBodies: true, RedactFields: []string{"password"}})(next)
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/login", strings.NewReader(`{"user":"ana","password":"s3cret"}`))
+	req.Header.Set("Authorization", "Bearer token-value")
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "token-value") || strings.Contains(out, "s3cret") {
+		t.Fatalf("log leaked secrets: %s", out)
+	}
+	if !strings.Contains(out, redacted) {
+		t.Fatalf("log has no redaction marker: %s", out)
+	}
+}
+
+func TestMetricsRecordsAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	var recorded []RequestMetrics
+	client := NewClient(ClientConfig{})
+	client.Use(Metrics(MetricsRecorderFunc(func(m RequestMetrics) {
+		recorded = append(recorded, m)
+	})))
+
+	client.Do(context.Background(), Request{Method: http.MethodGet, URL: server.URL + "/status"})
+	if len(recorded) != 1 || recorded[0].StatusCode != http.StatusTeapot || recorded[0].Path != "/status" {
+		t.Fatalf("metrics = %+v", recorded)
+	}
+}
@@ -0,0 +1,311 @@
+package call
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Pager define como a primeira pagina e pedida e como chegar a proxima a
+// partir da resposta anterior.
+type Pager interface {
+	First(req Request) Request
+	Next(req Request, resp *HTTPResponse, items int) (Request, bool)
+}
+
+type Pagination struct {
+	Pager     Pager
+	ItemsPath string // caminho dos itens no corpo, ex.: "data.items"; vazio = o corpo e a lista
+	MaxPages  int    // 0 = sem limite
+}
+
+// Paginate percorre todas as paginas da requisicao, entregando os itens um a
+// um. A iteracao para no primeiro erro, quando o contexto e cancelado ou
+// quando o laco do chamador e interrompido.
+func Paginate[T any](ctx context.Context, client *Client, req Request, pagination Pagination) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		if client == nil {
+			client = DefaultClient()
+		}
+		if pagination.Pager == nil {
+			yield(zero, fmt.Errorf("pagination: pager is required"))
+			return
+		}
+
+		req = pagination.Pager.First(req)
+		for page := 1; ; page++ {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			response, err := client.fetch(ctx, req)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if response.StatusCode < 200 || response.StatusCode > 299 {
+				yield(zero, &ResponseError{StatusCode: response.StatusCode, RawBody: response.RawBody})
+				return
+			}
+
+			items, err := pageItems[T](response.RawBody, pagination.ItemsPath)
+			if err != nil {
+				yield(zero, &ResponseError{StatusCode: response.StatusCode, RawBody: response.RawBody, Err: err})
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if pagination.MaxPages > 0 && page >= pagination.MaxPages {
+				return
+			}
+
+			next, ok := pagination.Pager.Next(req, response, len(items))
+			if !ok {
+				return
+			}
+			req = next
+		}
+	}
+}
+
+// PagePager usa um numero de pagina na query (?page=1, ?page=2...).
+type PagePager struct {
+	Param     string // padrao "page"
+	SizeParam string // opcional, ex.: "per_page"
+	Size      int    // com Size, uma pagina menor que Size encerra a paginacao
+	Start     int    // padrao 1
+}
+
+func (p PagePager) First(req Request) Request {
+	start := p.Start
+	if start == 0 {
+		start = 1
+	}
+	req = p.set(req, start)
+	return req
+}
+
+func (p PagePager) Next(req Request, _ *HTTPResponse, items int) (Request, bool) {
+	if items == 0 || (p.Size > 0 && items < p.Size) {
+		return req, false
+	}
+	current, _ := strconv.Atoi(req.Query.Get(p.param()))
+	return p.set(req, current+1), true
+}
+
+func (p PagePager) param() string {
+	if p.Param == "" {
+		return "page"
+	}
+	return p.Param
+}
+
+func (p PagePager) set(req Request, page int) Request {
+	req.Query = cloneValues(req.Query)
+	req.Query.Set(p.param(), strconv.Itoa(page))
+	if p.SizeParam != "" && p.Size > 0 {
+		req.Query.Set(p.SizeParam, strconv.Itoa(p.Size))
+	}
+	return req
+}
+
+// OffsetPager usa offset e limit na query (?offset=0&limit=100).
+type OffsetPager struct {
+	OffsetParam string // padrao "offset"
+	LimitParam  string // padrao "limit"
+	Limit       int    // padrao 100
+}
+
+func (p OffsetPager) First(req Request) Request {
+	return p.set(req, 0)
+}
+
+func (p OffsetPager) Next(req Request, _ *HTTPResponse, items int) (Request, bool) {
+	if items < p.limit() {
+		return req, false
+	}
+	offset, _ := strconv.Atoi(req.Query.Get(p.offsetParam()))
+	return p.set(req, offset+items), true
+}
+
+func (p OffsetPager) offsetParam() string {
+	if p.OffsetParam == "" {
+		return "offset"
+	}
+	return p.OffsetParam
+}
+
+func (p OffsetPager) limit() int {
+	if p.Limit <= 0 {
+		return 100
+	}
+	return p.Limit
+}
+
+func (p OffsetPager) set(req Request, offset int) Request {
+	limitParam := p.LimitParam
+	if limitParam == "" {
+		limitParam = "limit"
+	}
+
+	req.Query = cloneValues(req.Query)
+	req.Query.Set(p.offsetParam(), strconv.Itoa(offset))
+	req.Query.Set(limitParam, strconv.Itoa(p.limit()))
+	return req
+}
+
+// CursorPager le o cursor da proxima pagina do corpo da resposta e o envia
+// na query da requisicao seguinte.
+type CursorPager struct {
+	Param string // padrao "cursor"
+	Field string // caminho do cursor no corpo, ex.: "meta.next_cursor"
+}
+
+func (p CursorPager) First(req Request) Request {
+	return req
+}
+
+func (p CursorPager) Next(req Request, resp *HTTPResponse, _ int) (Request, bool) {
+	raw, err := lookupJSON(resp.RawBody, p.Field)
+	if err != nil {
+		return req, false
+	}
+
+	value, ok := cursorValue(raw)
+	if !ok {
+		return req, false
+	}
+
+	param := p.Param
+	if param == "" {
+		param = "cursor"
+	}
+	req.Query = cloneValues(req.Query)
+	req.Query.Set(param, value)
+	return req, true
+}
+
+// cursorValue devolve o cursor como texto. Strings sao desfeitas das aspas e
+// numeros seguem literalmente, sem passar por float64, para que cursores
+// inteiros grandes nao virem notacao cientifica.
+func cursorValue(raw json.RawMessage) (string, bool) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value, value != ""
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(raw, &number); err != nil {
+		return "", false
+	}
+	return number.String(), true
+}
+
+// LinkPager segue o cabecalho Link com rel="next" (RFC 8288).
+type LinkPager struct{}
+
+func (LinkPager) First(req Request) Request {
+	return req
+}
+
+func (LinkPager) Next(req Request, resp *HTTPResponse, _ int) (Request, bool) {
+	next := nextLink(resp.Header.Values("Link"))
+	if next == "" {
+		return req, false
+	}
+
+	ref, err := url.Parse(next)
+	if err != nil {
+		return req, false
+	}
+	if !ref.IsAbs() {
+		// Links relativos sao resolvidos contra a URL efetivamente
+		// requisitada (RFC 8288), que ja inclui o BaseURL do client.
+		base := resp.URL
+		if base == "" {
+			base = req.URL
+		}
+		if current, err := url.Parse(base); err == nil && current.IsAbs() {
+			ref = current.ResolveReference(ref)
+		}
+	}
+
+	// O link ja traz todos os parametros da proxima pagina.
+	req.URL = ref.String()
+	req.Query = nil
+	return req, true
+}
+
+var linkPattern = regexp.MustCompile(`<([^>]*)>((?:\s*;\s*[^;,]+)*)`)
+var relPattern = regexp.MustCompile(`(?i);\s*rel\s*=\s*"?([^";]+)"?`)
+
+func nextLink(headers []string) string {
+	for _, header := range headers {
+		for _, match := range linkPattern.FindAllStringSubmatch(header, -1) {
+			rel := relPattern.FindStringSubmatch(match[2])
+			if rel == nil {
+				continue
+			}
+			for _, value := range strings.Fields(rel[1]) {
+				if strings.EqualFold(value, "next") {
+					return match[1]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func pageItems[T any](body []byte, path string) ([]T, error) {
+	raw, err := lookupJSON(body, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []T
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// lookupJSON navega no JSON por um caminho separado por pontos.
+func lookupJSON(body []byte, path string) (json.RawMessage, error) {
+	raw := json.RawMessage(body)
+	if path == "" {
+		return raw, nil
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, fmt.Errorf("field %q: %w", key, err)
+		}
+		value, ok := object[key]
+		if !ok {
+			return nil, fmt.Errorf("field %q not found", key)
+		}
+		raw = value
+	}
+	return raw, nil
+}
+
+func cloneValues(values url.Values) url.Values {
+	clone := url.Values{}
+	for key, list := range values {
+		clone[key] = append([]string(nil), list...)
+	}
+	return clone
+}
@@ -0,0 +1,258 @@
+package call
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		want    string
+	}{
+		{"github style", []string{`<https://api.x/items?page=2>; rel="next", <https://api.x/items?page=5>; rel="last"`}, "https://api.x/items?page=2"},
+		{"next not first", []string{`<https://api.x/items?page=1>; rel="prev", <https://api.x/items?page=3>; rel="next"`}, "https://api.x/items?page=3"},
+		{"unquoted and case", []string{`</items?page=2>; REL=Next`}, "/items?page=2"},
+		{"multiple rel values", []string{`</items?page=2>; rel="next last"`}, "/items?page=2"},
+		{"extra params", []string{`</items?page=2>; title="a"; rel="next"`}, "/items?page=2"},
+		{"separate headers", []string{`</items?page=1>; rel="prev"`, `</items?page=3>; rel="next"`}, "/items?page=3"},
+		{"no next", []string{`</items?page=1>; rel="prev"`}, ""},
+		{"next in other rel", []string{`</items?page=2>; rel="nextpage"`}, ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextLink(tt.headers); got != tt.want {
+				t.Fatalf("nextLink = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// pagedServer serve os numeros 1..total com a paginacao escolhida pelo path.
+func pagedServer(t *testing.T, total int) *httptest.Server {
+	t.Helper()
+	items := func(from, to int) []int {
+		list := []int{}
+		for i := from; i <= to && i <= total; i++ {
+			list = append(list, i)
+		}
+		return list
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("filter") != "x" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/page":
+			page, _ := strconv.Atoi(query.Get("page"))
+			size, _ := strconv.Atoi(query.Get("per_page"))
+			json.NewEncoder(w).Encode(items((page-1)*size+1, page*size))
+		case "/offset":
+			offset, _ := strconv.Atoi(query.Get("offset"))
+			limit, _ := strconv.Atoi(query.Get("limit"))
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"items": items(offset+1, offset+limit)}})
+		case "/cursor":
+			start, _ := strconv.Atoi(query.Get("cursor"))
+			page := items(start+1, start+2)
+			var next interface{}
+			if start+2 < total {
+				next = strconv.Itoa(start + 2)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"items": page, "meta": map[string]interface{}{"next": next}})
+		case "/link":
+			start, _ := strconv.Atoi(query.Get("after"))
+			if start+2 < total {
+				w.Header().Set("Link", fmt.Sprintf(`<link?filter=x&after=%d>; rel="next"`, start+2))
+			}
+			json.NewEncoder(w).Encode(items(start+1, start+2))
+		case "/error":
+			if query.Get("page") == "2" {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			json.NewEncoder(w).Encode([]int{1, 2})
+		}
+	}))
+}
+
+func collect(t *testing.T, seq func(func(int, error) bool)) ([]int, error) {
+	t.Helper()
+	var got []int
+	for item, err := range seq {
+		if err != nil {
+			return got, err
+		}
+		got = append(got, item)
+	}
+	return got, nil
+}
+
+func TestPaginatePagers(t *testing.T) {
+	server := pagedServer(t, 5)
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		pagination Pagination
+	}{
+		{"page", "/page", Pagination{Pager: PagePager{SizeParam: "per_page", Size: 2}}},
+		{"offset", "/offset", Pagination{Pager: OffsetPager{Limit: 2}, ItemsPath: "data.items"}},
+		{"cursor", "/cursor", Pagination{Pager: CursorPager{Field: "meta.next"}, ItemsPath: "items"}},
+		{"link", "/link", Pagination{Pager: LinkPager{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := Request{URL: server.URL + tt.path, Query: url.Values{"filter": {"x"}}}
+			got, err := collect(t, Paginate[int](context.Background(), nil, req, tt.pagination))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != "[1 2 3 4 5]" {
+				t.Fatalf("items = %v", got)
+			}
+			if len(req.Query) != 1 {
+				t.Fatalf("caller query was mutated: %v", req.Query)
+			}
+		})
+	}
+}
+
+func TestPaginateStopsEarly(t *testing.T) {
+	server := pagedServer(t, 10)
+	defer server.Close()
+	req := Request{URL: server.URL + "/page", Query: url.Values{"filter": {"x"}}}
+
+	got, _ := collect(t, Paginate[int](context.Background(), nil, req, Pagination{Pager: PagePager{SizeParam: "per_page", Size: 2}, MaxPages: 2}))
+	if fmt.Sprint(got) != "[1 2 3 4]" {
+		t.Fatalf("MaxPages items = %v", got)
+	}
+
+	got = nil
+	for item := range Paginate[int](context.Background(), nil, req, Pagination{Pager: PagePager{SizeParam: "per_page", Size: 2}}) {
+		got = append(got, item)
+		if item == 3 {
+			break
+		}
+	}
+	if fmt.Sprint(got) != "[1 2 3]" {
+		t.Fatalf("break items = %v", got)
+	}
+}
+
+func TestPaginateErrors(t *testing.T) {
+	server := pagedServer(t, 10)
+	defer server.Close()
+	req := Request{URL: server.URL + "/error", Query: url.Values{"filter": {"x"}}}
+
+	got, err := collect(t, Paginate[int](context.Background(), nil, req, Pagination{Pager: PagePager{}}))
+	responseErr, ok := err.(*ResponseError)
+	if fmt.Sprint(got) != "[1 2]" || !ok || responseErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("items = %v, err = %v", got, err)
+	}
+
+	_, err = collect(t, Paginate[int](context.Background(), nil, req, Pagination{Pager: PagePager{}, ItemsPath: "missing"}))
+	if err == nil {
+		t.Fatal("expected error for missing ItemsPath")
+	}
+
+	_, err = collect(t, Paginate[int](context.Background(), nil, req, Pagination{}))
+	if err == nil {
+		t.Fatal("expected error without pager")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = collect(t, Paginate[int](ctx, nil, req, Pagination{Pager: PagePager{}})); err != context.Canceled {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestCursorValue(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{`"abc"`, "abc", true},
+		{`12345678`, "12345678", true},
+		{`9007199254740993`, "9007199254740993", true},
+		{`1.5`, "1.5", true},
+		{`""`, "", false},
+		{`null`, "", false},
+		{`true`, "", false},
+		{`{"a":1}`, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := cursorValue(json.RawMessage(tt.raw))
+		if got != tt.want || ok != tt.ok {
+			t.Fatalf("cursorValue(%s) = %q, %v; want %q, %v", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPaginateNumericCursor(t *testing.T) {
+	var cursors []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("cursor")
+		cursors = append(cursors, cursor)
+		if cursor == "" {
+			fmt.Fprint(w, `{"items":[1],"next_cursor":12345678901234567}`)
+			return
+		}
+		fmt.Fprint(w, `{"items":[2],"next_cursor":null}`)
+	}))
+	defer server.Close()
+
+	got, err := collect(t, Paginate[int](context.Background(), nil, Request{URL: server.URL}, Pagination{Pager: CursorPager{Field: "next_cursor"}, ItemsPath: "items"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "[1 2]" {
+		t.Fatalf("items = %v", got)
+	}
+	if len(cursors) != 2 || cursors[1] != "12345678901234567" {
+		t.Fatalf("cursors = %q", cursors)
+	}
+}
+
+func TestPaginateRelativeLinkWithBaseURLPath(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RequestURI())
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", `</api/items?page=2>; rel="next"`)
+			fmt.Fprint(w, `[1]`)
+			return
+		}
+		fmt.Fprint(w, `[2]`)
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{BaseURL: server.URL + "/api"})
+	got, err := collect(t, Paginate[int](context.Background(), client, Request{URL: "/items"}, Pagination{Pager: LinkPager{}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "[1 2]" {
+		t.Fatalf("items = %v", got)
+	}
+	if len(paths) != 2 || paths[0] != "/api/items" || paths[1] != "/api/items?page=2" {
+		t.Fatalf("paths = %q", paths)
+	}
+}
@@ -0,0 +1,120 @@
+package call
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type RateLimitConfig struct {
+	RequestsPerSecond float64
+	Burst             int  // padrao 1
+	PerHost           bool // um limite por host em vez de um limite para o client inteiro
+	// Adaptive pausa o envio quando a resposta traz X-RateLimit-Remaining: 0
+	// (ate X-RateLimit-Reset) ou Retry-After em 429/503.
+	Adaptive bool
+}
+
+// RateLimiter e um token bucket que bloqueia ate haver token disponivel ou o
+// contexto ser cancelado.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu          sync.Mutex
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		wait := l.reserve(time.Now())
+		if wait <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// PauseUntil impede novos envios ate o instante informado.
+func (l *RateLimiter) PauseUntil(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// observe ajusta o limite a partir dos cabecalhos de rate limit da resposta.
+func (l *RateLimiter) observe(resp *http.Response) {
+	now := time.Now()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			l.PauseUntil(now.Add(wait))
+			return
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || reset <= 0 {
+		return
+	}
+
+	// Alguns provedores enviam o epoch do reset, outros os segundos restantes.
+	if reset > 1_000_000_000 {
+		l.PauseUntil(time.Unix(reset, 0))
+	} else {
+		l.PauseUntil(now.Add(time.Duration(reset) * time.Second))
+	}
+}
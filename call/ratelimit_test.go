@@ -0,0 +1,124 @@
+package call
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurstAndRefill(t *testing.T) {
+	limiter := NewRateLimiter(10, 2)
+	now := limiter.last
+
+	if limiter.reserve(now) != 0 || limiter.reserve(now) != 0 {
+		t.Fatal("burst tokens should be available immediately")
+	}
+	if wait := limiter.reserve(now); wait < 90*time.Millisecond || wait > 100*time.Millisecond {
+		t.Fatalf("wait = %s, want about 100ms", wait)
+	}
+	if wait := limiter.reserve(now.Add(time.Second)); wait != 0 {
+		t.Fatalf("wait after refill = %s, want 0", wait)
+	}
+}
+
+func TestRateLimiterWaitRespectsContext(t *testing.T) {
+	limiter := NewRateLimiter(0.1, 1)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestRateLimiterPauseUntil(t *testing.T) {
+	limiter := NewRateLimiter(0, 1)
+	now := time.Now()
+
+	limiter.PauseUntil(now.Add(time.Minute))
+	limiter.PauseUntil(now.Add(time.Second))
+	if wait := limiter.reserve(now); wait != time.Minute {
+		t.Fatalf("wait = %s, an earlier pause must not shorten the current one", wait)
+	}
+}
+
+func TestRateLimiterObserve(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"retry-after on 429", http.StatusTooManyRequests, map[string]string{"Retry-After": "30"}, 29 * time.Second, 30 * time.Second},
+		{"remaining zero with seconds", http.StatusOK, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "60"}, 59 * time.Second, 60 * time.Second},
+		{"remaining zero with epoch", http.StatusOK, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(time.Now().Add(2*time.Minute).Unix(), 10)}, 118 * time.Second, 120 * time.Second},
+		{"remaining left", http.StatusOK, map[string]string{"X-RateLimit-Remaining": "3", "X-RateLimit-Reset": "60"}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(0, 1)
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			for key, value := range tt.headers {
+				resp.Header.Set(key, value)
+			}
+
+			limiter.observe(resp)
+			if wait := limiter.reserve(time.Now()); wait < tt.min || wait > tt.max {
+				t.Fatalf("wait = %s, want between %s and %s", wait, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestClientRateLimitPerHost(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	first := httptest.NewServer(handler)
+	defer first.Close()
+	second := httptest.NewServer(handler)
+	defer second.Close()
+
+	client := NewClient(ClientConfig{RateLimit: &RateLimitConfig{RequestsPerSecond: 1, Burst: 1, PerHost: true}})
+	start := time.Now()
+	for _, url := range []string{first.URL, second.URL} {
+		if _, err := client.Do(context.Background(), Request{URL: url}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("requests to different hosts waited %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Do(ctx, Request{URL: first.URL}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the second request to the same host to wait", err)
+	}
+}
+
+func TestClientAdaptiveRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "60")
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{RateLimit: &RateLimitConfig{RequestsPerSecond: 100, Burst: 10, Adaptive: true}})
+	if _, err := client.Do(context.Background(), Request{URL: server.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Do(ctx, Request{URL: server.URL}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want client paused until the reset", err)
+	}
+}
@@ -0,0 +1,76 @@
+package call
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxRetries int           // padrao 0 (sem retry)
+	BaseDelay  time.Duration // padrao 200 milissegundos
+	MaxDelay   time.Duration // padrao 10 segundos
+	// RetryOn substitui a regra padrao (erros de rede, 429 e 5xx).
+	RetryOn func(resp *http.Response, err error) bool
+}
+
+func (p *RetryPolicy) validate() {
+	if p.MaxRetries < 0 {
+		p.MaxRetries = 0
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 200 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 10 * time.Second
+	}
+}
+
+func (p RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	if p.RetryOn != nil {
+		return p.RetryOn(resp, err)
+	}
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff calcula a espera antes da proxima tentativa: exponencial com full
+// jitter, a menos que a resposta traga um Retry-After.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait
+		}
+	}
+
+	delay := p.MaxDelay
+	if attempt < 32 {
+		if exp := p.BaseDelay << attempt; exp > 0 && exp < p.MaxDelay {
+			delay = exp
+		}
+	}
+	return rand.N(delay) + 1
+}
+
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
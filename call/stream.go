@@ -0,0 +1,145 @@
+package call
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+)
+
+// BodyTooLargeError indica que a resposta ultrapassou ClientConfig.MaxBodySize.
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("response body exceeds limit of %d bytes", e.Limit)
+}
+
+type StreamResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       io.ReadCloser
+}
+
+// Stream executa a requisicao e devolve o corpo sem le-lo. O chamador deve
+// fechar Body. O Timeout do client vale para cada tentativa apenas ate a
+// chegada dos cabecalhos de resposta; a leitura do corpo e controlada pelo
+// contexto.
+func (c *Client) Stream(ctx context.Context, req Request) (*StreamResponse, error) {
+	resp, err := c.send(ctx, c.streamClient, req, c.config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       c.limitBody(resp),
+	}, nil
+}
+
+// StreamLines executa a requisicao e decodifica a resposta JSON lines
+// (NDJSON) item a item. Status fora da faixa 2xx gera *ResponseError.
+func StreamLines[T any](ctx context.Context, client *Client, req Request) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		if client == nil {
+			client = DefaultClient()
+		}
+
+		resp, err := client.Stream(ctx, req)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			rawBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+			yield(zero, &ResponseError{StatusCode: resp.StatusCode, RawBody: rawBody})
+			return
+		}
+
+		for item, err := range DecodeLines[T](resp.Body) {
+			if !yield(item, err) {
+				return
+			}
+		}
+	}
+}
+
+// DecodeLines decodifica uma sequencia de valores JSON separados por quebra
+// de linha. A iteracao termina no fim do reader ou no primeiro erro.
+func DecodeLines[T any](r io.Reader) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		decoder := json.NewDecoder(r)
+		for {
+			var item T
+			err := decoder.Decode(&item)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(item, fmt.Errorf("failed to decode json line: %w", err))
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) limitBody(resp *http.Response) io.ReadCloser {
+	if c.config.MaxBodySize <= 0 {
+		return resp.Body
+	}
+	if resp.ContentLength > c.config.MaxBodySize {
+		resp.Body.Close()
+		return io.NopCloser(errorReader{&BodyTooLargeError{Limit: c.config.MaxBodySize}})
+	}
+	return &limitedBody{ReadCloser: resp.Body, limit: c.config.MaxBodySize}
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	limit int64
+	read  int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.read > b.limit {
+		return 0, &BodyTooLargeError{Limit: b.limit}
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return n - int(b.read-b.limit), &BodyTooLargeError{Limit: b.limit}
+	}
+	return n, err
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,203 @@
+package call
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	body := strings.Repeat("a", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", "100")
+		}
+		w.Write([]byte(body))
+		w.(http.Flusher).Flush()
+	}))
+	defer server.Close()
+
+	for _, query := range []string{"", "?chunked=1"} {
+		client := NewClient(ClientConfig{MaxBodySize: 10})
+		_, err := client.Do(context.Background(), Request{URL: server.URL + query})
+		var tooLarge *BodyTooLargeError
+		if !errors.As(err, &tooLarge) || tooLarge.Limit != 10 {
+			t.Fatalf("query %q: err = %v, want BodyTooLargeError", query, err)
+		}
+	}
+
+	client := NewClient(ClientConfig{MaxBodySize: 100})
+	resp, err := client.Do(context.Background(), Request{URL: server.URL + "?chunked=1"})
+	if err != nil || string(resp.RawBody) != body {
+		t.Fatalf("body at the limit: err = %v", err)
+	}
+}
+
+func TestLimitedBodyReturnsDataUpToLimit(t *testing.T) {
+	body := &limitedBody{ReadCloser: io.NopCloser(strings.NewReader("abcdef")), limit: 4}
+	data, err := io.ReadAll(body)
+	var tooLarge *BodyTooLargeError
+	if string(data) != "abcd" || !errors.As(err, &tooLarge) {
+		t.Fatalf("data = %q, err = %v", data, err)
+	}
+}
+
+func TestStreamReadsBodyAfterTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first\n"))
+		w.(http.Flusher).Flush()
+		time.Sleep(80 * time.Millisecond)
+		w.Write([]byte("second\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{Timeout: 30 * time.Millisecond})
+	resp, err := client.Stream(context.Background(), Request{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil || string(data) != "first\nsecond\n" {
+		t.Fatalf("data = %q, err = %v", data, err)
+	}
+}
+
+func TestStreamHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(ClientConfig{Timeout: 30 * time.Millisecond})
+	if _, err := client.Stream(context.Background(), Request{URL: server.URL}); err == nil || !strings.Contains(err.Error(), "timeout awaiting response headers") {
+		t.Fatalf("err = %v, want header timeout", err)
+	}
+}
+
+func TestStreamCanceledByContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := NewClient(ClientConfig{}).Stream(ctx, Request{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Fatal("expected read error after context cancellation")
+	}
+}
+
+func TestStreamLines(t *testing.T) {
+	type event struct {
+		ID int `json:"id"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/events":
+			w.Write([]byte("{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n"))
+		case "/broken":
+			w.Write([]byte("{\"id\":1}\nnot json\n"))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`denied`))
+		}
+	}))
+	defer server.Close()
+	client := NewClient(ClientConfig{BaseURL: server.URL})
+
+	var ids []int
+	for item, err := range StreamLines[event](context.Background(), client, Request{URL: "/events"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, item.ID)
+		if item.ID == 2 {
+			break
+		}
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("ids = %v, want [1 2] with early break", ids)
+	}
+
+	var errs []error
+	for _, err := range StreamLines[event](context.Background(), client, Request{URL: "/broken"}) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 2 || errs[0] != nil || errs[1] == nil {
+		t.Fatalf("errs = %v, want one item then a decode error", errs)
+	}
+
+	for _, err := range StreamLines[event](context.Background(), client, Request{URL: "/denied"}) {
+		var responseErr *ResponseError
+		if !errors.As(err, &responseErr) || responseErr.StatusCode != http.StatusForbidden || string(responseErr.RawBody) != "denied" {
+			t.Fatalf("err = %v, want ResponseError 403", err)
+		}
+	}
+}
+
+func TestStreamHeaderTimeoutPerAttempt(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		time.Sleep(35 * time.Millisecond)
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// As duas tentativas somam mais que o Timeout, mas cada uma cabe nele.
+	client := NewClient(ClientConfig{
+		Timeout: 50 * time.Millisecond,
+		Retry:   RetryPolicy{MaxRetries: 1, BaseDelay: 20 * time.Millisecond, MaxDelay: 20 * time.Millisecond},
+	})
+	resp, err := client.Stream(context.Background(), Request{URL: server.URL})
+	if err != nil {
+		t.Fatalf("err = %v, want the retry to get its own header timeout", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(data) != "ok" || attempts != 2 {
+		t.Fatalf("status = %d, body = %q, attempts = %d", resp.StatusCode, data, attempts)
+	}
+}
+
+func TestStreamHeaderTimeoutCountsAsBreakerFailure(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(ClientConfig{Timeout: 30 * time.Millisecond, Breaker: &BreakerConfig{ConsecutiveFailures: 1}})
+	if _, err := client.Stream(context.Background(), Request{URL: server.URL}); err == nil {
+		t.Fatal("expected header timeout")
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	if state := client.BreakerState(host); state != StateOpen {
+		t.Fatalf("breaker state = %v, want open after a header timeout", state)
+	}
+}
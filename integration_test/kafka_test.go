@@ -0,0 +1,47 @@
+package integration_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/simpplify-org/GO-data-connector-lib/queue"
+)
+
+// ------------------ Test Kafka ------------------
+
+func TestKafkaIntegration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	topic := "teste-topico-" + time.Now().Format("20060102150405")
+	kafka := queue.NewToKafka(queue.KafkaConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   topic,
+		GroupId: "teste-grupo",
+	})
+	defer kafka.Close()
+
+	_, err := kafka.SendMessage([]byte("mensagem de teste"), "cliente-1", queue.WithMessageAttribute("tipo", "teste"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgCh, err := kafka.Consume(ctx, queue.ConsumerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, ok := <-msgCh
+	if !ok {
+		t.Fatal("consumer finalizado sem mensagens")
+	}
+	if *msg.Body != "mensagem de teste" || msg.Attributes["MessageGroupId"] != "cliente-1" {
+		t.Fatalf("mensagem inesperada: %v", *msg.Body)
+	}
+
+	err = kafka.DeleteMessage(ctx, msg.ReceiptHandle)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,103 @@
+package integration_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/simpplify-org/GO-data-connector-lib/queue"
+)
+
+// ------------------ Test SQS ------------------
+
+func newLocalSqs() *queue.ToSqs {
+	sqsClient := queue.NewToSqs("test", "test", "us-east-1", "")
+	sqsClient.Endpoint = "http://localhost:4566"
+	return sqsClient
+}
+
+func TestSqsStandardIntegration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sqsClient := newLocalSqs()
+	_, err := sqsClient.CreateQueue(ctx, "teste-fila", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqsClient.DeleteQueue(ctx)
+
+	_, err = sqsClient.SendMessage([]byte("mensagem de teste"), "", queue.WithMessageAttribute("tipo", "teste"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := sqsClient.SendMessageBatch(ctx, []queue.BatchEntry{
+		{Body: []byte("lote 1")},
+		{Body: []byte("lote 2")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	}
+
+	attributes, err := sqsClient.GetQueueAttributes(ctx, types.QueueAttributeNameApproximateNumberOfMessages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attributes["ApproximateNumberOfMessages"] != "3" {
+		t.Fatalf("esperava 3 mensagens, obteve %s", attributes["ApproximateNumberOfMessages"])
+	}
+
+	err = sqsClient.PurgeQueue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSqsFifoIntegration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sqsClient := newLocalSqs()
+	_, err := sqsClient.CreateQueue(ctx, "teste-fila.fifo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqsClient.DeleteQueue(ctx)
+
+	// O mesmo id de deduplicacao deve resultar em uma unica mensagem.
+	for i := 0; i < 2; i++ {
+		_, err = sqsClient.SendMessage([]byte("pedido 1"), "pedidos", queue.WithDeduplicationId("pedido-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msgCh, err := sqsClient.Consume(ctx, queue.ConsumerConfig{WaitTimeSeconds: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := <-msgCh
+	if *msg.Body != "pedido 1" || queue.ReceiveCount(msg) != 1 {
+		t.Fatalf("mensagem inesperada: %v", *msg.Body)
+	}
+	err = sqsClient.DeleteMessage(ctx, msg.ReceiptHandle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attributes, err := sqsClient.GetQueueAttributes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attributes["ApproximateNumberOfMessages"] != "0" {
+		t.Fatalf("esperava fila vazia, obteve %s", attributes["ApproximateNumberOfMessages"])
+	}
+}
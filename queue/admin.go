@@ -0,0 +1,92 @@
+package queue
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// CreateQueue cria a fila (ou obtem a existente com os mesmos atributos) e
+// passa a usa-la em QueueUrl. Nomes terminados em .fifo criam filas FIFO.
+// attributes aceita os atributos do SQS, ex.: "VisibilityTimeout" ou
+// "ContentBasedDeduplication". QueueUrl e trocado sob o mesmo lock do
+// cliente, mas as demais operacoes o leem sem lock: chame CreateQueue antes
+// de compartilhar o ToSqs entre goroutines.
+func (q *ToSqs) CreateQueue(ctx context.Context, name string, attributes map[string]string) (string, error) {
+	client, err := q.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	attrs := map[string]string{}
+	for key, value := range attributes {
+		attrs[key] = value
+	}
+	if strings.HasSuffix(name, ".fifo") {
+		attrs[string(types.QueueAttributeNameFifoQueue)] = "true"
+	}
+
+	resp, err := client.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName:  aws.String(name),
+		Attributes: attrs,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	url := aws.ToString(resp.QueueUrl)
+	q.mu.Lock()
+	q.QueueUrl = url
+	q.mu.Unlock()
+	return url, nil
+}
+
+func (q *ToSqs) DeleteQueue(ctx context.Context) error {
+	client, err := q.getClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteQueue(ctx, &sqs.DeleteQueueInput{
+		QueueUrl: aws.String(q.QueueUrl),
+	})
+	return err
+}
+
+// PurgeQueue remove todas as mensagens da fila. O SQS permite uma chamada a
+// cada 60 segundos por fila.
+func (q *ToSqs) PurgeQueue(ctx context.Context) error {
+	client, err := q.getClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.PurgeQueue(ctx, &sqs.PurgeQueueInput{
+		QueueUrl: aws.String(q.QueueUrl),
+	})
+	return err
+}
+
+// GetQueueAttributes devolve os atributos pedidos, ou todos quando nenhum e
+// informado, ex.: ApproximateNumberOfMessages.
+func (q *ToSqs) GetQueueAttributes(ctx context.Context, names ...types.QueueAttributeName) (map[string]string, error) {
+	client, err := q.getClient()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		names = []types.QueueAttributeName{types.QueueAttributeNameAll}
+	}
+
+	resp, err := client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(q.QueueUrl),
+		AttributeNames: names,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Attributes, nil
+}
@@ -0,0 +1,188 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	maxBatchEntries = 10
+	maxBatchBytes   = 256 * 1024
+)
+
+type BatchEntry struct {
+	Body           []byte
+	MessageGroupId string // obrigatorio em filas FIFO
+	Options        []SendOption
+}
+
+// BatchResult e o resultado de uma entrada, na mesma posicao da entrada
+// correspondente.
+type BatchResult struct {
+	MessageId string
+	Err       error
+}
+
+// BatchEntryError e a falha devolvida pelo SQS para uma entrada do lote.
+type BatchEntryError struct {
+	Code        string
+	Message     string
+	SenderFault bool
+}
+
+func (e *BatchEntryError) Error() string {
+	return fmt.Sprintf("sqs batch entry failed: %s: %s", e.Code, e.Message)
+}
+
+// SendMessageBatch envia as mensagens em lotes de ate 10 entradas e 256 KB.
+// O erro retornado indica apenas falha ao criar o client; falhas de envio
+// ficam no resultado de cada entrada.
+func (q *ToSqs) SendMessageBatch(ctx context.Context, entries []BatchEntry) ([]BatchResult, error) {
+	client, err := q.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(entries))
+	params := make([]sendParams, len(entries))
+	bodies := make([][]byte, len(entries))
+	sizes := make([]int, len(entries))
+	for i, entry := range entries {
+		params[i] = newSendParams(entry.Body, entry.MessageGroupId, q.isFifo(), entry.Options)
+		if bodies[i], err = q.offload(ctx, entry.Body, &params[i]); err != nil {
+			results[i].Err = err
+			sizes[i] = -1
+			continue
+		}
+		sizes[i] = messageSize(bodies[i], params[i].attributes)
+	}
+
+	for _, chunk := range chunkEntries(sizes, results) {
+		input := &sqs.SendMessageBatchInput{QueueUrl: aws.String(q.QueueUrl)}
+		for _, i := range chunk {
+			input.Entries = append(input.Entries, types.SendMessageBatchRequestEntry{
+				Id:                     aws.String(strconv.Itoa(i)),
+				MessageBody:            aws.String(string(bodies[i])),
+				MessageGroupId:         params[i].messageGroupId,
+				MessageDeduplicationId: params[i].deduplicationId,
+				DelaySeconds:           params[i].delaySeconds,
+				MessageAttributes:      params[i].attributes,
+			})
+		}
+
+		resp, err := client.SendMessageBatch(ctx, input)
+		if err != nil {
+			failChunk(results, chunk, err)
+			continue
+		}
+		for _, ok := range resp.Successful {
+			if i, found := entryIndex(ok.Id, len(results)); found {
+				results[i].MessageId = aws.ToString(ok.MessageId)
+			}
+		}
+		for _, failed := range resp.Failed {
+			if i, found := entryIndex(failed.Id, len(results)); found {
+				results[i].Err = batchEntryError(failed)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// DeleteMessageBatch deleta as mensagens em lotes de ate 10 receipt handles.
+func (q *ToSqs) DeleteMessageBatch(ctx context.Context, receiptHandles []*string) ([]BatchResult, error) {
+	client, err := q.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(receiptHandles))
+	handles := make([]*string, len(receiptHandles))
+	pointers := make([]*payloadPointer, len(receiptHandles))
+	for i, handle := range receiptHandles {
+		handles[i], pointers[i] = splitReceiptHandle(handle)
+	}
+
+	for _, chunk := range chunkEntries(make([]int, len(receiptHandles)), results) {
+		input := &sqs.DeleteMessageBatchInput{QueueUrl: aws.String(q.QueueUrl)}
+		for _, i := range chunk {
+			input.Entries = append(input.Entries, types.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(i)),
+				ReceiptHandle: handles[i],
+			})
+		}
+
+		resp, err := client.DeleteMessageBatch(ctx, input)
+		if err != nil {
+			failChunk(results, chunk, err)
+			continue
+		}
+		for _, failed := range resp.Failed {
+			if i, found := entryIndex(failed.Id, len(results)); found {
+				results[i].Err = batchEntryError(failed)
+			}
+		}
+		for _, i := range chunk {
+			if results[i].Err == nil {
+				results[i].Err = q.deletePayload(ctx, pointers[i])
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// chunkEntries agrupa os indices respeitando os limites de quantidade e de
+// tamanho do SQS. Entradas que sozinhas excedem o tamanho maximo sao marcadas
+// com erro e ficam fora dos lotes.
+func chunkEntries(sizes []int, results []BatchResult) [][]int {
+	var chunks [][]int
+	var current []int
+	currentBytes := 0
+
+	for i, size := range sizes {
+		if size < 0 {
+			// entrada ja marcada com erro
+			continue
+		}
+		if size > maxBatchBytes {
+			results[i].Err = fmt.Errorf("sqs batch entry failed: message of %d bytes exceeds the %d bytes limit", size, maxBatchBytes)
+			continue
+		}
+		if len(current) == maxBatchEntries || currentBytes+size > maxBatchBytes {
+			chunks = append(chunks, current)
+			current, currentBytes = nil, 0
+		}
+		current = append(current, i)
+		currentBytes += size
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+func failChunk(results []BatchResult, chunk []int, err error) {
+	for _, i := range chunk {
+		results[i].Err = err
+	}
+}
+
+func entryIndex(id *string, total int) (int, bool) {
+	i, err := strconv.Atoi(aws.ToString(id))
+	return i, err == nil && i >= 0 && i < total
+}
+
+func batchEntryError(entry types.BatchResultErrorEntry) error {
+	return &BatchEntryError{
+		Code:        aws.ToString(entry.Code),
+		Message:     aws.ToString(entry.Message),
+		SenderFault: entry.SenderFault,
+	}
+}
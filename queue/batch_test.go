@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func md5Hex(body string) string {
+	sum := md5.Sum([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+func repeatSizes(n, size int) []int {
+	sizes := make([]int, n)
+	for i := range sizes {
+		sizes[i] = size
+	}
+	return sizes
+}
+
+func TestChunkEntries(t *testing.T) {
+	tests := []struct {
+		name   string
+		sizes  []int
+		chunks [][]int
+		failed []int
+	}{
+		{"empty", nil, nil, nil},
+		{"single chunk", []int{10, 20, 30}, [][]int{{0, 1, 2}}, nil},
+		{"ten entries per chunk", repeatSizes(12, 1), [][]int{{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {10, 11}}, nil},
+		{"exactly ten", repeatSizes(10, 1), [][]int{{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}}, nil},
+		{"total bytes", []int{100 * 1024, 100 * 1024, 100 * 1024}, [][]int{{0, 1}, {2}}, nil},
+		{"exactly the byte limit", []int{128 * 1024, 128 * 1024, 1}, [][]int{{0, 1}, {2}}, nil},
+		{"oversized entry", []int{10, maxBatchBytes + 1, 20}, [][]int{{0, 2}}, []int{1}},
+		{"previous failure", []int{10, -1, 20}, [][]int{{0, 2}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make([]BatchResult, len(tt.sizes))
+			chunks := chunkEntries(tt.sizes, results)
+			if !reflect.DeepEqual(chunks, tt.chunks) {
+				t.Fatalf("chunks = %v, want %v", chunks, tt.chunks)
+			}
+
+			var failed []int
+			for i, result := range results {
+				if result.Err != nil {
+					failed = append(failed, i)
+				}
+			}
+			if !reflect.DeepEqual(failed, tt.failed) {
+				t.Fatalf("failed entries = %v, want %v", failed, tt.failed)
+			}
+		})
+	}
+}
+
+func TestEntryIndex(t *testing.T) {
+	tests := []struct {
+		id    *string
+		want  int
+		found bool
+	}{
+		{aws.String("0"), 0, true},
+		{aws.String("4"), 4, true},
+		{aws.String("5"), 5, false},
+		{aws.String("-1"), -1, false},
+		{aws.String("x"), 0, false},
+		{nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		i, found := entryIndex(tt.id, 5)
+		if found != tt.found || (found && i != tt.want) {
+			t.Fatalf("entryIndex(%v) = %d, %v; want %d, %v", aws.ToString(tt.id), i, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestFailChunk(t *testing.T) {
+	results := make([]BatchResult, 4)
+	err := errors.New("boom")
+	failChunk(results, []int{1, 3}, err)
+
+	for i, result := range results {
+		want := i == 1 || i == 3
+		if (result.Err == err) != want {
+			t.Fatalf("results[%d].Err = %v", i, result.Err)
+		}
+	}
+}
+
+func TestSendMessageBatchMapsResultsToEntries(t *testing.T) {
+	fake := newFakeSQS(t, func(operation string, input map[string]interface{}) interface{} {
+		var successful, failed []interface{}
+		for _, raw := range input["Entries"].([]interface{}) {
+			entry := raw.(map[string]interface{})
+			id := entry["Id"].(string)
+			if entry["MessageBody"] == "falha" {
+				failed = append(failed, map[string]interface{}{"Id": id, "Code": "InvalidMessageContents", "Message": "invalid", "SenderFault": true})
+				continue
+			}
+			successful = append(successful, map[string]interface{}{"Id": id, "MessageId": "m" + id, "MD5OfMessageBody": md5Hex(entry["MessageBody"].(string))})
+		}
+		return map[string]interface{}{"Successful": successful, "Failed": failed}
+	})
+	q := fake.queue("pedidos")
+
+	var entries []BatchEntry
+	for i := 0; i < 12; i++ {
+		body := fmt.Sprint(i)
+		if i == 3 || i == 11 {
+			body = "falha"
+		}
+		entries = append(entries, BatchEntry{Body: []byte(body)})
+	}
+	entries = append(entries, BatchEntry{Body: []byte(strings.Repeat("x", maxBatchBytes+1))})
+
+	results, err := q.SendMessageBatch(context.Background(), entries)
+	if err != nil {
+		t.Fatalf("SendMessageBatch: %v", err)
+	}
+	if calls := fake.calls("SendMessageBatch"); len(calls) != 2 {
+		t.Fatalf("SendMessageBatch calls = %d, want 2", len(calls))
+	}
+
+	for i, result := range results {
+		switch i {
+		case 3, 11:
+			var entryErr *BatchEntryError
+			if !errors.As(result.Err, &entryErr) || entryErr.Code != "InvalidMessageContents" || !entryErr.SenderFault {
+				t.Fatalf("results[%d].Err = %v, want BatchEntryError", i, result.Err)
+			}
+		case 12:
+			if result.Err == nil || !strings.Contains(result.Err.Error(), "exceeds") {
+				t.Fatalf("results[12].Err = %v, want size error", result.Err)
+			}
+		default:
+			if result.Err != nil || result.MessageId != fmt.Sprintf("m%d", i) {
+				t.Fatalf("results[%d] = %+v, want MessageId m%d", i, result, i)
+			}
+		}
+	}
+}
+
+func TestDeleteMessageBatchReportsFailedEntries(t *testing.T) {
+	fake := newFakeSQS(t, func(operation string, input map[string]interface{}) interface{} {
+		var successful, failed []interface{}
+		for _, raw := range input["Entries"].([]interface{}) {
+			entry := raw.(map[string]interface{})
+			if entry["ReceiptHandle"] == "expirado" {
+				failed = append(failed, map[string]interface{}{"Id": entry["Id"], "Code": "ReceiptHandleIsInvalid", "SenderFault": true})
+				continue
+			}
+			successful = append(successful, map[string]interface{}{"Id": entry["Id"]})
+		}
+		return map[string]interface{}{"Successful": successful, "Failed": failed}
+	})
+	q := fake.queue("pedidos")
+
+	handles := []*string{aws.String("a"), aws.String("expirado"), aws.String("c")}
+	results, err := q.DeleteMessageBatch(context.Background(), handles)
+	if err != nil {
+		t.Fatalf("DeleteMessageBatch: %v", err)
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Fatalf("results = %+v, want entries 0 and 2 deleted", results)
+	}
+	var entryErr *BatchEntryError
+	if !errors.As(results[1].Err, &entryErr) || entryErr.Code != "ReceiptHandleIsInvalid" {
+		t.Fatalf("results[1].Err = %v, want ReceiptHandleIsInvalid", results[1].Err)
+	}
+}
@@ -0,0 +1,280 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Atributos adicionados a mensagem movida para a DLQ. Eles contam no limite de
+// 10 atributos por mensagem do SQS.
+const (
+	AttributeFailureReason = "dlq_failure_reason"
+	AttributeSourceQueue   = "dlq_source_queue"
+	AttributeAttempts      = "dlq_attempts"
+	// AttributeOriginalAttributes guarda, em JSON, os atributos originais
+	// quando eles e os dlq_* nao cabem juntos no limite do SQS. O redrive os
+	// restaura.
+	AttributeOriginalAttributes = "dlq_original_attributes"
+)
+
+const (
+	maxFailureReason     = 1024
+	maxMessageAttributes = 10
+)
+
+type DeadLetterConfig struct {
+	Queue       Publisher // fila para onde vao as mensagens que esgotaram as tentativas
+	MaxAttempts int       // padrao 5 recebimentos
+}
+
+// ReceiveCount devolve quantas vezes a mensagem foi recebida, pelo atributo
+// ApproximateReceiveCount. Mensagens sem o atributo contam como 1.
+func ReceiveCount(msg types.Message) int {
+	count, err := strconv.Atoi(msg.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+// MoveToDLQ envia a mensagem para a DLQ com o motivo da falha nos atributos
+// e a remove da fila de origem. O corpo, o grupo e os atributos originais sao
+// preservados.
+func (q *ToSqs) MoveToDLQ(ctx context.Context, msg types.Message, dlq Publisher, reason error) error {
+	return moveToDLQ(ctx, q, msg, dlq, reason)
+}
+
+func moveToDLQ(ctx context.Context, source Consumer, msg types.Message, dlq Publisher, reason error) error {
+	body := []byte(aws.ToString(msg.Body))
+	receiptHandle := msg.ReceiptHandle
+	attributes := map[string]types.MessageAttributeValue{}
+	for name, value := range msg.MessageAttributes {
+		attributes[name] = value
+	}
+
+	// Um corpo que veio do S3 segue para a DLQ como o ponteiro original, que
+	// cabe em qualquer fila. A origem e deletada pelo receipt handle puro para
+	// que o objeto no S3 nao seja removido junto.
+	if handle, pointer := splitReceiptHandle(msg.ReceiptHandle); pointer != nil {
+		encoded, err := json.Marshal([]interface{}{payloadPointerClass, pointer})
+		if err != nil {
+			return fmt.Errorf("failed to send message to dead-letter queue: %w", err)
+		}
+		attributes[AttributeExtendedPayloadSize] = types.MessageAttributeValue{
+			DataType:    aws.String("Number"),
+			StringValue: aws.String(strconv.Itoa(len(body))),
+		}
+		body, receiptHandle = encoded, handle
+	}
+
+	attributes, err := packAttributes(attributes, 3)
+	if err != nil {
+		return fmt.Errorf("failed to send message to dead-letter queue: %w", err)
+	}
+
+	failure := "unknown"
+	if reason != nil {
+		failure = reason.Error()
+	}
+	if len(failure) > maxFailureReason {
+		failure = failure[:maxFailureReason]
+	}
+	attributes[AttributeFailureReason] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(failure)}
+	attributes[AttributeSourceQueue] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(queueName(source))}
+	attributes[AttributeAttempts] = types.MessageAttributeValue{DataType: aws.String("Number"), StringValue: aws.String(strconv.Itoa(ReceiveCount(msg)))}
+
+	// O MessageId original como id de deduplicacao evita duplicar a mensagem
+	// na DLQ se a remocao da origem falhar e a mensagem for movida de novo.
+	_, err = dlq.SendMessage(body, messageGroup(msg),
+		WithMessageAttributes(attributes),
+		WithDeduplicationId(aws.ToString(msg.MessageId)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to send message to dead-letter queue: %w", err)
+	}
+
+	return source.DeleteMessage(ctx, receiptHandle)
+}
+
+// packAttributes agrupa os atributos em AttributeOriginalAttributes quando
+// eles, somados aos reserved atributos da DLQ, passariam do limite do SQS. O
+// ponteiro do S3 fica de fora, pois o consumer da DLQ precisa dele.
+func packAttributes(attributes map[string]types.MessageAttributeValue, reserved int) (map[string]types.MessageAttributeValue, error) {
+	if len(attributes)+reserved <= maxMessageAttributes {
+		return attributes, nil
+	}
+
+	packed := map[string]types.MessageAttributeValue{}
+	original := map[string]types.MessageAttributeValue{}
+	for name, value := range attributes {
+		if name == AttributeExtendedPayloadSize || name == legacyPayloadSizeAttribute {
+			packed[name] = value
+		} else {
+			original[name] = value
+		}
+	}
+
+	encoded, err := json.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+	packed[AttributeOriginalAttributes] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(string(encoded))}
+	return packed, nil
+}
+
+// unpackAttributes desfaz packAttributes e remove os atributos dlq_*.
+func unpackAttributes(attributes map[string]types.MessageAttributeValue) (map[string]types.MessageAttributeValue, error) {
+	restored := map[string]types.MessageAttributeValue{}
+	for name, value := range attributes {
+		if !strings.HasPrefix(name, "dlq_") {
+			restored[name] = value
+		}
+	}
+
+	packed, ok := attributes[AttributeOriginalAttributes]
+	if !ok {
+		return restored, nil
+	}
+	var original map[string]types.MessageAttributeValue
+	if err := json.Unmarshal([]byte(aws.ToString(packed.StringValue)), &original); err != nil {
+		return nil, fmt.Errorf("invalid %s attribute: %w", AttributeOriginalAttributes, err)
+	}
+	for name, value := range original {
+		restored[name] = value
+	}
+	return restored, nil
+}
+
+type RedriveConfig struct {
+	// Filter seleciona as mensagens reenviadas; as demais permanecem na DLQ.
+	Filter            func(msg types.Message) bool
+	RequestsPerSecond float64 // limite de reenvios por segundo; 0 = sem limite
+	MaxMessages       int     // 0 = ate esvaziar a DLQ
+	// VisibilityTimeout mantem as mensagens lidas invisiveis durante o
+	// redrive, para que as ignoradas pelo filtro nao sejam lidas de novo;
+	// padrao 300 segundos.
+	VisibilityTimeout int32
+}
+
+type RedriveResult struct {
+	Moved   int
+	Skipped int
+	Failed  int
+}
+
+// Redrive le as mensagens desta fila (a DLQ) e as reenvia para target,
+// removendo os atributos de falha. Termina quando a DLQ nao devolve mais
+// mensagens, quando MaxMessages e atingido ou quando o contexto e cancelado.
+func (q *ToSqs) Redrive(ctx context.Context, target Publisher, cfg RedriveConfig) (RedriveResult, error) {
+	client, err := q.getClient()
+	if err != nil {
+		return RedriveResult{}, err
+	}
+
+	return redrive(ctx, q, target, cfg, func(ctx context.Context, visibilityTimeout int32) ([]types.Message, error) {
+		resp, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:                    aws.String(q.QueueUrl),
+			MaxNumberOfMessages:         10,
+			WaitTimeSeconds:             1,
+			VisibilityTimeout:           visibilityTimeout,
+			MessageAttributeNames:       []string{"All"},
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Messages, nil
+	})
+}
+
+// Redrive reenvia as mensagens desta fila para target, como ToSqs.Redrive.
+func (q *MemoryQueue) Redrive(ctx context.Context, target Publisher, cfg RedriveConfig) (RedriveResult, error) {
+	return redrive(ctx, q, target, cfg, func(ctx context.Context, visibilityTimeout int32) ([]types.Message, error) {
+		messages, _, _ := q.receive(10, visibilityTimeout)
+		return messages, nil
+	})
+}
+
+// redrive le lotes da DLQ com receive ate ela nao devolver mais mensagens.
+func redrive(ctx context.Context, dlq Consumer, target Publisher, cfg RedriveConfig, receive func(ctx context.Context, visibilityTimeout int32) ([]types.Message, error)) (RedriveResult, error) {
+	var result RedriveResult
+
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = 300
+	}
+
+	var throttle <-chan time.Time
+	if cfg.RequestsPerSecond > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.RequestsPerSecond))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		messages, err := receive(ctx, cfg.VisibilityTimeout)
+		if err != nil {
+			return result, err
+		}
+		if len(messages) == 0 {
+			return result, nil
+		}
+
+		for _, msg := range messages {
+			if cfg.MaxMessages > 0 && result.Moved >= cfg.MaxMessages {
+				return result, nil
+			}
+			if cfg.Filter != nil && !cfg.Filter(msg) {
+				result.Skipped++
+				continue
+			}
+
+			if throttle != nil {
+				select {
+				case <-throttle:
+				case <-ctx.Done():
+					return result, ctx.Err()
+				}
+			}
+
+			if err := redriveMessage(ctx, dlq, msg, target); err != nil {
+				fmt.Println("Erro ao reenviar mensagem da DLQ:", err)
+				result.Failed++
+				continue
+			}
+			result.Moved++
+		}
+	}
+}
+
+// redriveMessage so remove a mensagem da DLQ depois que o envio para target
+// foi aceito.
+func redriveMessage(ctx context.Context, dlq Consumer, msg types.Message, target Publisher) error {
+	attributes, err := unpackAttributes(msg.MessageAttributes)
+	if err != nil {
+		return err
+	}
+
+	_, err = target.SendMessage([]byte(aws.ToString(msg.Body)), messageGroup(msg),
+		WithMessageAttributes(attributes),
+		WithDeduplicationId(aws.ToString(msg.MessageId)),
+	)
+	if err != nil {
+		return err
+	}
+	return dlq.DeleteMessage(ctx, msg.ReceiptHandle)
+}
+
+func messageGroup(msg types.Message) string {
+	return msg.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]
+}
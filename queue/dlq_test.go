@@ -0,0 +1,266 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// receiveOne le a proxima mensagem visivel da fila.
+func receiveOne(t *testing.T, q *MemoryQueue) types.Message {
+	t.Helper()
+	received, _, _ := q.receive(1, 30)
+	if len(received) != 1 {
+		t.Fatalf("received %d messages, want 1", len(received))
+	}
+	return received[0]
+}
+
+func stringAttribute(msg types.Message, name string) string {
+	return aws.ToString(msg.MessageAttributes[name].StringValue)
+}
+
+func TestMoveToDLQForwardsLargePayloadPointer(t *testing.T) {
+	ctx := context.Background()
+	source := NewMemoryQueue(MemoryQueueConfig{Name: "source"})
+	dlq := NewMemoryQueue(MemoryQueueConfig{Name: "dlq"})
+
+	pointerBody := `["` + payloadPointerClass + `",{"s3BucketName":"bucket","s3Key":"key"}]`
+	source.SendMessage([]byte(pointerBody), "")
+	msg := receiveOne(t, source)
+
+	// Simula a mensagem depois de resolvePayload.
+	payload := strings.Repeat("x", 300*1024)
+	msg.Body = aws.String(payload)
+	msg.ReceiptHandle = aws.String(receiptBucketMarker + "bucket" + receiptBucketMarker +
+		receiptKeyMarker + "key" + receiptKeyMarker + aws.ToString(msg.ReceiptHandle))
+
+	if err := moveToDLQ(ctx, source, msg, dlq, errors.New("boom")); err != nil {
+		t.Fatalf("moveToDLQ: %v", err)
+	}
+	if source.Len() != 0 {
+		t.Fatalf("source Len = %d, want 0", source.Len())
+	}
+
+	moved := receiveOne(t, dlq)
+	pointer, err := parsePointer(aws.ToString(moved.Body))
+	if err != nil || pointer.S3BucketName != "bucket" || pointer.S3Key != "key" {
+		t.Fatalf("DLQ body = %q, want the S3 pointer", aws.ToString(moved.Body))
+	}
+	if size := stringAttribute(moved, AttributeExtendedPayloadSize); size != fmt.Sprint(len(payload)) {
+		t.Fatalf("%s = %q, want %d", AttributeExtendedPayloadSize, size, len(payload))
+	}
+}
+
+func TestMoveToDLQPacksAttributesOverLimit(t *testing.T) {
+	ctx := context.Background()
+	source := NewMemoryQueue(MemoryQueueConfig{Name: "source"})
+	dlq := NewMemoryQueue(MemoryQueueConfig{Name: "dlq"})
+
+	attributes := map[string]types.MessageAttributeValue{}
+	for i := 0; i < 9; i++ {
+		attributes[fmt.Sprintf("a%d", i)] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(fmt.Sprint(i))}
+	}
+	source.SendMessage([]byte("hello"), "", WithMessageAttributes(attributes))
+
+	if err := moveToDLQ(ctx, source, receiveOne(t, source), dlq, errors.New("boom")); err != nil {
+		t.Fatalf("moveToDLQ: %v", err)
+	}
+	moved := receiveOne(t, dlq)
+	if n := len(moved.MessageAttributes); n > maxMessageAttributes {
+		t.Fatalf("DLQ message has %d attributes, limit is %d", n, maxMessageAttributes)
+	}
+	if _, ok := moved.MessageAttributes["a0"]; ok {
+		t.Fatal("original attributes were not packed")
+	}
+
+	restored, err := unpackAttributes(moved.MessageAttributes)
+	if err != nil {
+		t.Fatalf("unpackAttributes: %v", err)
+	}
+	if len(restored) != 9 || stringAttribute(types.Message{MessageAttributes: restored}, "a8") != "8" {
+		t.Fatalf("restored attributes = %v", restored)
+	}
+}
+
+func TestMoveToDLQKeepsAttributesUnderLimit(t *testing.T) {
+	ctx := context.Background()
+	source := NewMemoryQueue(MemoryQueueConfig{Name: "source"})
+	dlq := NewMemoryQueue(MemoryQueueConfig{Name: "dlq"})
+
+	source.SendMessage([]byte("hello"), "", WithMessageAttribute("tenant", "acme"))
+	if err := moveToDLQ(ctx, source, receiveOne(t, source), dlq, errors.New("boom")); err != nil {
+		t.Fatalf("moveToDLQ: %v", err)
+	}
+
+	moved := receiveOne(t, dlq)
+	if len(moved.MessageAttributes) != 4 || stringAttribute(moved, "tenant") != "acme" {
+		t.Fatalf("attributes = %v", moved.MessageAttributes)
+	}
+	if _, ok := moved.MessageAttributes[AttributeOriginalAttributes]; ok {
+		t.Fatal("attributes packed below the limit")
+	}
+}
+
+// failingPublisher recusa todos os envios.
+type failingPublisher struct{}
+
+func (failingPublisher) SendMessage(message []byte, messageGroupId string, opts ...SendOption) (*sqs.SendMessageOutput, error) {
+	return nil, errors.New("target unavailable")
+}
+
+func TestMoveToDLQSetsFailureAttributes(t *testing.T) {
+	ctx := context.Background()
+	source := NewMemoryQueue(MemoryQueueConfig{Name: "pedidos.fifo", FIFO: true})
+	dlq := NewMemoryQueue(MemoryQueueConfig{Name: "pedidos-dlq.fifo", FIFO: true})
+
+	source.SendMessage([]byte("hello"), "grupo", WithMessageAttribute("tenant", "acme"))
+	receiveOne(t, source)
+	expire(source)
+	msg := receiveOne(t, source)
+
+	reason := errors.New(strings.Repeat("e", 2000))
+	if err := moveToDLQ(ctx, source, msg, dlq, reason); err != nil {
+		t.Fatalf("moveToDLQ: %v", err)
+	}
+	if source.Len() != 0 {
+		t.Fatalf("source Len = %d, want 0", source.Len())
+	}
+
+	moved := receiveOne(t, dlq)
+	if aws.ToString(moved.Body) != "hello" || messageGroup(moved) != "grupo" || stringAttribute(moved, "tenant") != "acme" {
+		t.Fatalf("moved message = %v, %v", aws.ToString(moved.Body), moved.MessageAttributes)
+	}
+	if got := stringAttribute(moved, AttributeFailureReason); len(got) != maxFailureReason {
+		t.Fatalf("failure reason has %d bytes, want it truncated to %d", len(got), maxFailureReason)
+	}
+	if got := stringAttribute(moved, AttributeSourceQueue); got != "pedidos.fifo" {
+		t.Fatalf("%s = %q", AttributeSourceQueue, got)
+	}
+	if got := stringAttribute(moved, AttributeAttempts); got != "2" {
+		t.Fatalf("%s = %q, want 2", AttributeAttempts, got)
+	}
+	if got := moved.Attributes[string(types.MessageSystemAttributeNameMessageDeduplicationId)]; got != aws.ToString(msg.MessageId) {
+		t.Fatalf("deduplication id = %q, want the source MessageId", got)
+	}
+}
+
+func TestMoveToDLQKeepsMessageWhenSendFails(t *testing.T) {
+	source := NewMemoryQueue(MemoryQueueConfig{Name: "source"})
+	source.SendMessage([]byte("hello"), "")
+
+	if err := moveToDLQ(context.Background(), source, receiveOne(t, source), failingPublisher{}, errors.New("boom")); err == nil {
+		t.Fatal("moveToDLQ succeeded with a failing DLQ")
+	}
+	if source.Len() != 1 {
+		t.Fatalf("source Len = %d, want the message kept", source.Len())
+	}
+}
+
+// deadLetters preenche uma DLQ em memoria com as mensagens movidas.
+func deadLetters(t *testing.T, bodies ...string) *MemoryQueue {
+	t.Helper()
+	source := NewMemoryQueue(MemoryQueueConfig{Name: "source"})
+	dlq := NewMemoryQueue(MemoryQueueConfig{Name: "dlq"})
+	for _, body := range bodies {
+		source.SendMessage([]byte(body), "", WithMessageAttribute("tenant", "acme"))
+		if err := moveToDLQ(context.Background(), source, receiveOne(t, source), dlq, errors.New("boom")); err != nil {
+			t.Fatalf("moveToDLQ: %v", err)
+		}
+	}
+	return dlq
+}
+
+func TestRedriveRemovesFailureAttributes(t *testing.T) {
+	dlq := deadLetters(t, "a", "b")
+	target := NewMemoryQueue(MemoryQueueConfig{Name: "target"})
+
+	result, err := dlq.Redrive(context.Background(), target, RedriveConfig{})
+	if err != nil {
+		t.Fatalf("Redrive: %v", err)
+	}
+	if result != (RedriveResult{Moved: 2}) {
+		t.Fatalf("result = %+v, want 2 moved", result)
+	}
+	if dlq.Len() != 0 || target.Len() != 2 {
+		t.Fatalf("dlq = %d, target = %d; want all messages moved", dlq.Len(), target.Len())
+	}
+
+	msg := receiveOne(t, target)
+	if len(msg.MessageAttributes) != 1 || stringAttribute(msg, "tenant") != "acme" {
+		t.Fatalf("attributes = %v, want only the original ones", msg.MessageAttributes)
+	}
+}
+
+func TestRedriveRestoresPackedAttributes(t *testing.T) {
+	source := NewMemoryQueue(MemoryQueueConfig{Name: "source"})
+	dlq := NewMemoryQueue(MemoryQueueConfig{Name: "dlq"})
+	target := NewMemoryQueue(MemoryQueueConfig{Name: "target"})
+
+	attributes := map[string]types.MessageAttributeValue{}
+	for i := 0; i < 9; i++ {
+		attributes[fmt.Sprintf("a%d", i)] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(fmt.Sprint(i))}
+	}
+	source.SendMessage([]byte("hello"), "", WithMessageAttributes(attributes))
+	moveToDLQ(context.Background(), source, receiveOne(t, source), dlq, errors.New("boom"))
+
+	if _, err := dlq.Redrive(context.Background(), target, RedriveConfig{}); err != nil {
+		t.Fatalf("Redrive: %v", err)
+	}
+	msg := receiveOne(t, target)
+	if len(msg.MessageAttributes) != 9 || stringAttribute(msg, "a8") != "8" {
+		t.Fatalf("attributes = %v, want the 9 originals restored", msg.MessageAttributes)
+	}
+}
+
+func TestRedriveFilter(t *testing.T) {
+	dlq := deadLetters(t, "keep", "move", "keep", "move")
+	target := NewMemoryQueue(MemoryQueueConfig{Name: "target"})
+
+	result, err := dlq.Redrive(context.Background(), target, RedriveConfig{
+		Filter: func(msg types.Message) bool { return aws.ToString(msg.Body) == "move" },
+	})
+	if err != nil {
+		t.Fatalf("Redrive: %v", err)
+	}
+	if result != (RedriveResult{Moved: 2, Skipped: 2}) {
+		t.Fatalf("result = %+v, want 2 moved and 2 skipped", result)
+	}
+	if dlq.Len() != 2 || target.Len() != 2 {
+		t.Fatalf("dlq = %d, target = %d", dlq.Len(), target.Len())
+	}
+}
+
+func TestRedriveMaxMessages(t *testing.T) {
+	dlq := deadLetters(t, "a", "b", "c")
+	target := NewMemoryQueue(MemoryQueueConfig{Name: "target"})
+
+	result, err := dlq.Redrive(context.Background(), target, RedriveConfig{MaxMessages: 2})
+	if err != nil {
+		t.Fatalf("Redrive: %v", err)
+	}
+	if result.Moved != 2 || dlq.Len() != 1 || target.Len() != 2 {
+		t.Fatalf("result = %+v, dlq = %d, target = %d; want 2 moved", result, dlq.Len(), target.Len())
+	}
+}
+
+func TestRedriveKeepsMessagesWhenTargetFails(t *testing.T) {
+	dlq := deadLetters(t, "a", "b")
+
+	result, err := dlq.Redrive(context.Background(), failingPublisher{}, RedriveConfig{})
+	if err != nil {
+		t.Fatalf("Redrive: %v", err)
+	}
+	if result != (RedriveResult{Failed: 2}) {
+		t.Fatalf("result = %+v, want 2 failed", result)
+	}
+	if dlq.Len() != 2 {
+		t.Fatalf("dlq Len = %d, want messages kept after a failed publish", dlq.Len())
+	}
+}
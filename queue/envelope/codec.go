@@ -0,0 +1,138 @@
+package envelope
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec converte o payload para o corpo da mensagem. O corpo precisa ser
+// texto valido, entao codecs binarios devem codificar em base64.
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string {
+	return "application/json"
+}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// ProtoCodec serializa mensagens protobuf em binario codificado em base64. O
+// payload deve implementar proto.Message, ex.: Publisher[*pb.Pedido].
+type ProtoCodec struct{}
+
+func (ProtoCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("envelope: %T does not implement proto.Message", v)
+	}
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(data)), nil
+}
+
+func (ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	message, ok := protoTarget(v)
+	if !ok {
+		return fmt.Errorf("envelope: %T does not implement proto.Message", v)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(decoded, message)
+}
+
+// protoTarget aceita tanto *pb.Pedido quanto **pb.Pedido, alocando a
+// mensagem quando o ponteiro interno e nil.
+func protoTarget(v interface{}) (proto.Message, bool) {
+	if message, ok := v.(proto.Message); ok {
+		return message, true
+	}
+
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Ptr {
+		return nil, false
+	}
+	inner := value.Elem()
+	if inner.IsNil() {
+		inner.Set(reflect.New(inner.Type().Elem()))
+	}
+	message, ok := inner.Interface().(proto.Message)
+	return message, ok
+}
+
+// GzipCodec comprime o resultado de outro codec (padrao JSON) e codifica em
+// base64, reduzindo payloads grandes e repetitivos.
+type GzipCodec struct {
+	Codec Codec
+}
+
+func (c GzipCodec) ContentType() string {
+	return c.inner().ContentType() + "+gzip"
+}
+
+func (c GzipCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := c.inner().Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+func (c GzipCodec) Unmarshal(data []byte, v interface{}) error {
+	compressed, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return c.inner().Unmarshal(decoded, v)
+}
+
+func (c GzipCodec) inner() Codec {
+	if c.Codec == nil {
+		return JSONCodec{}
+	}
+	return c.Codec
+}
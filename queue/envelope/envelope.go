@@ -0,0 +1,208 @@
+package envelope
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/google/uuid"
+	"github.com/simpplify-org/GO-data-connector-lib/queue"
+)
+
+// Atributos SQS que carregam os metadados do envelope. Os demais atributos do
+// tipo String sao tratados como headers (ex.: traceparent), exceto os
+// reservados da biblioteca (queue.IsReservedAttribute) e os envelope_*.
+const (
+	AttributeID          = "envelope_id"
+	AttributeType        = "envelope_type"
+	AttributeVersion     = "envelope_version"
+	AttributeTimestamp   = "envelope_timestamp"
+	AttributeContentType = "content_type"
+)
+
+type Envelope[T any] struct {
+	ID        string
+	Type      string
+	Version   string
+	Timestamp time.Time
+	Headers   map[string]string
+	Payload   T
+}
+
+type headersKey struct{}
+
+// WithHeaders adiciona headers ao contexto; Publish os envia junto com a
+// mensagem. O Consumer entrega ao handler um contexto com os headers
+// recebidos, propagando trace ids entre servicos.
+func WithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := map[string]string{}
+	for key, value := range HeadersFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range headers {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+func HeadersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	return headers
+}
+
+type PublisherConfig struct {
+	Type    string // tipo do evento, ex.: "pedido.criado"
+	Version string // padrao "1"
+	Codec   Codec  // padrao JSONCodec
+}
+
+// Publisher envia payloads do tipo T dentro de um envelope.
+type Publisher[T any] struct {
+	queue  queue.Publisher
+	config PublisherConfig
+}
+
+func NewPublisher[T any](q queue.Publisher, config PublisherConfig) *Publisher[T] {
+	if config.Version == "" {
+		config.Version = "1"
+	}
+	if config.Codec == nil {
+		config.Codec = JSONCodec{}
+	}
+	return &Publisher[T]{queue: q, config: config}
+}
+
+// Publish envia o payload e devolve o envelope enviado. messageGroupId segue
+// as regras de ToSqs.SendMessage.
+func (p *Publisher[T]) Publish(ctx context.Context, payload T, messageGroupId string, opts ...queue.SendOption) (Envelope[T], error) {
+	env := Envelope[T]{
+		ID:        uuid.New().String(),
+		Type:      p.config.Type,
+		Version:   p.config.Version,
+		Timestamp: time.Now().UTC(),
+		Headers:   HeadersFromContext(ctx),
+		Payload:   payload,
+	}
+
+	body, err := p.config.Codec.Marshal(payload)
+	if err != nil {
+		return env, fmt.Errorf("envelope: failed to encode payload: %w", err)
+	}
+
+	attributes := map[string]types.MessageAttributeValue{}
+	for name, value := range env.Headers {
+		attributes[name] = stringAttribute(value)
+	}
+	attributes[AttributeID] = stringAttribute(env.ID)
+	attributes[AttributeVersion] = stringAttribute(env.Version)
+	attributes[AttributeTimestamp] = stringAttribute(env.Timestamp.Format(time.RFC3339Nano))
+	attributes[AttributeContentType] = stringAttribute(p.config.Codec.ContentType())
+	if env.Type != "" {
+		attributes[AttributeType] = stringAttribute(env.Type)
+	}
+
+	opts = append([]queue.SendOption{queue.WithMessageAttributes(attributes)}, opts...)
+	if _, err := p.queue.SendMessage(body, messageGroupId, opts...); err != nil {
+		return env, err
+	}
+	return env, nil
+}
+
+type Handler[T any] func(ctx context.Context, env Envelope[T]) error
+
+type ConsumerConfig struct {
+	Codec Codec // padrao JSONCodec
+	// Types restringe os tipos aceitos; mensagens de outros tipos falham na
+	// decodificacao. Vazio aceita qualquer tipo.
+	Types []string
+}
+
+// Consumer decodifica mensagens em Envelope[T] antes de chamar o handler.
+type Consumer[T any] struct {
+	queue   queue.Consumer
+	config  ConsumerConfig
+	handler Handler[T]
+}
+
+func NewConsumer[T any](q queue.Consumer, config ConsumerConfig, handler Handler[T]) *Consumer[T] {
+	if config.Codec == nil {
+		config.Codec = JSONCodec{}
+	}
+	return &Consumer[T]{queue: q, config: config, handler: handler}
+}
+
+// Run processa a fila com um queue.Processor, deletando as mensagens cujo
+// handler retorna nil.
+func (c *Consumer[T]) Run(ctx context.Context, config queue.ProcessorConfig) error {
+	return queue.NewProcessor(c.queue, config, c.Handle).Run(ctx)
+}
+
+// Handle implementa queue.Handler, permitindo usar o Consumer com um
+// Processor configurado pelo chamador.
+func (c *Consumer[T]) Handle(ctx context.Context, msg types.Message) error {
+	env, err := c.Decode(msg)
+	if err != nil {
+		return err
+	}
+	if len(env.Headers) > 0 {
+		ctx = WithHeaders(ctx, env.Headers)
+	}
+	return c.handler(ctx, env)
+}
+
+func (c *Consumer[T]) Decode(msg types.Message) (Envelope[T], error) {
+	env := Envelope[T]{Headers: map[string]string{}}
+
+	for name, value := range msg.MessageAttributes {
+		if value.StringValue == nil {
+			continue
+		}
+		switch name {
+		case AttributeID:
+			env.ID = *value.StringValue
+		case AttributeType:
+			env.Type = *value.StringValue
+		case AttributeVersion:
+			env.Version = *value.StringValue
+		case AttributeTimestamp:
+			env.Timestamp, _ = time.Parse(time.RFC3339Nano, *value.StringValue)
+		case AttributeContentType:
+			if contentType := *value.StringValue; contentType != c.config.Codec.ContentType() {
+				return env, fmt.Errorf("envelope: unexpected content type %q, consumer expects %q", contentType, c.config.Codec.ContentType())
+			}
+		default:
+			if !isReserved(name) {
+				env.Headers[name] = *value.StringValue
+			}
+		}
+	}
+
+	if len(c.config.Types) > 0 && !contains(c.config.Types, env.Type) {
+		return env, fmt.Errorf("envelope: unexpected message type %q", env.Type)
+	}
+
+	if err := c.config.Codec.Unmarshal([]byte(aws.ToString(msg.Body)), &env.Payload); err != nil {
+		return env, fmt.Errorf("envelope: failed to decode payload: %w", err)
+	}
+	return env, nil
+}
+
+func stringAttribute(value string) types.MessageAttributeValue {
+	return types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+}
+
+func isReserved(name string) bool {
+	return queue.IsReservedAttribute(name) || strings.HasPrefix(name, "envelope_")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
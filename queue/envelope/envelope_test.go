@@ -0,0 +1,179 @@
+package envelope
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/simpplify-org/GO-data-connector-lib/queue"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type pedido struct {
+	ID    string `json:"id"`
+	Itens []int  `json:"itens"`
+}
+
+// receive le a proxima mensagem da fila.
+func receive(t *testing.T, q *queue.MemoryQueue) types.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	msgCh, err := q.Consume(ctx, queue.ConsumerConfig{})
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	msg, ok := <-msgCh
+	if !ok {
+		t.Fatal("no message received")
+	}
+	return msg
+}
+
+func TestRoundTripCodecs(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+	}{
+		{"json", JSONCodec{}},
+		{"gzip", GzipCodec{}},
+		{"gzip json explicit", GzipCodec{Codec: JSONCodec{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := queue.NewMemoryQueue(queue.MemoryQueueConfig{})
+			payload := pedido{ID: "p-1", Itens: []int{1, 2, 3}}
+
+			sent, err := NewPublisher[pedido](q, PublisherConfig{Type: "pedido.criado", Codec: tt.codec}).Publish(context.Background(), payload, "")
+			if err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+
+			env, err := NewConsumer(q, ConsumerConfig{Codec: tt.codec}, Handler[pedido](nil)).Decode(receive(t, q))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if env.ID != sent.ID || env.Type != "pedido.criado" || env.Version != "1" || !env.Timestamp.Equal(sent.Timestamp) {
+				t.Fatalf("envelope = %+v, want %+v", env, sent)
+			}
+			if env.Payload.ID != "p-1" || len(env.Payload.Itens) != 3 {
+				t.Fatalf("payload = %+v", env.Payload)
+			}
+		})
+	}
+}
+
+func TestRoundTripProtoCodec(t *testing.T) {
+	for _, codec := range []Codec{ProtoCodec{}, GzipCodec{Codec: ProtoCodec{}}} {
+		q := queue.NewMemoryQueue(queue.MemoryQueueConfig{})
+		if _, err := NewPublisher[*wrapperspb.StringValue](q, PublisherConfig{Codec: codec}).Publish(context.Background(), wrapperspb.String("olá"), ""); err != nil {
+			t.Fatalf("%s: Publish: %v", codec.ContentType(), err)
+		}
+
+		env, err := NewConsumer(q, ConsumerConfig{Codec: codec}, Handler[*wrapperspb.StringValue](nil)).Decode(receive(t, q))
+		if err != nil {
+			t.Fatalf("%s: Decode: %v", codec.ContentType(), err)
+		}
+		if env.Payload.GetValue() != "olá" {
+			t.Fatalf("%s: payload = %v", codec.ContentType(), env.Payload)
+		}
+	}
+
+	if _, err := (ProtoCodec{}).Marshal(pedido{}); err == nil {
+		t.Fatal("ProtoCodec accepted a payload that is not a proto.Message")
+	}
+}
+
+func TestDecodeRejectsContentTypeMismatch(t *testing.T) {
+	q := queue.NewMemoryQueue(queue.MemoryQueueConfig{})
+	NewPublisher[pedido](q, PublisherConfig{Codec: GzipCodec{}}).Publish(context.Background(), pedido{ID: "p-1"}, "")
+
+	_, err := NewConsumer(q, ConsumerConfig{}, Handler[pedido](nil)).Decode(receive(t, q))
+	if err == nil || !strings.Contains(err.Error(), "unexpected content type") {
+		t.Fatalf("err = %v, want content type mismatch", err)
+	}
+}
+
+func TestDecodeSkipsReservedAttributes(t *testing.T) {
+	msg := types.Message{
+		Body: aws.String(`{"id":"p-1"}`),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			AttributeContentType:               stringAttribute("application/json"),
+			"traceparent":                      stringAttribute("00-abc-01"),
+			queue.AttributeFailureReason:       stringAttribute("boom"),
+			queue.AttributeSourceQueue:         stringAttribute("pedidos"),
+			queue.AttributeAttempts:            stringAttribute("5"),
+			queue.AttributeExtendedPayloadSize: stringAttribute("300000"),
+			"SQSLargePayloadSize":              stringAttribute("300000"),
+			"envelope_futuro":                  stringAttribute("x"),
+		},
+	}
+
+	env, err := NewConsumer(nil, ConsumerConfig{}, Handler[pedido](nil)).Decode(msg)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(env.Headers) != 1 || env.Headers["traceparent"] != "00-abc-01" {
+		t.Fatalf("headers = %v, want only traceparent", env.Headers)
+	}
+}
+
+func TestHeadersPropagation(t *testing.T) {
+	if headers := HeadersFromContext(context.Background()); headers != nil {
+		t.Fatalf("headers = %v, want nil", headers)
+	}
+
+	ctx := WithHeaders(context.Background(), map[string]string{"traceparent": "00-abc-01", "tenant": "a"})
+	ctx = WithHeaders(ctx, map[string]string{"tenant": "b"})
+	if headers := HeadersFromContext(ctx); headers["traceparent"] != "00-abc-01" || headers["tenant"] != "b" {
+		t.Fatalf("merged headers = %v", headers)
+	}
+
+	q := queue.NewMemoryQueue(queue.MemoryQueueConfig{})
+	sent, err := NewPublisher[pedido](q, PublisherConfig{}).Publish(ctx, pedido{ID: "p-1"}, "")
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if sent.Headers["tenant"] != "b" {
+		t.Fatalf("sent headers = %v", sent.Headers)
+	}
+
+	var received map[string]string
+	consumer := NewConsumer(q, ConsumerConfig{}, func(ctx context.Context, env Envelope[pedido]) error {
+		received = HeadersFromContext(ctx)
+		return nil
+	})
+	if err := consumer.Handle(context.Background(), receive(t, q)); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if len(received) != 2 || received["traceparent"] != "00-abc-01" || received["tenant"] != "b" {
+		t.Fatalf("handler headers = %v", received)
+	}
+}
+
+func TestConsumerHandleFiltersTypes(t *testing.T) {
+	q := queue.NewMemoryQueue(queue.MemoryQueueConfig{})
+	var handled []string
+	consumer := NewConsumer(q, ConsumerConfig{Types: []string{"pedido.criado"}}, func(ctx context.Context, env Envelope[pedido]) error {
+		handled = append(handled, env.Type)
+		return nil
+	})
+
+	NewPublisher[pedido](q, PublisherConfig{Type: "pedido.cancelado"}).Publish(context.Background(), pedido{}, "")
+	if err := consumer.Handle(context.Background(), receive(t, q)); err == nil || !strings.Contains(err.Error(), "unexpected message type") {
+		t.Fatalf("err = %v, want unexpected message type", err)
+	}
+
+	NewPublisher[pedido](q, PublisherConfig{Type: "Pedido.Criado"}).Publish(context.Background(), pedido{}, "")
+	if err := consumer.Handle(context.Background(), receive(t, q)); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if len(handled) != 1 || handled[0] != "Pedido.Criado" {
+		t.Fatalf("handled = %v, want only the accepted type", handled)
+	}
+}
@@ -0,0 +1,142 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileQueue e uma MemoryQueue persistida em um diretorio local, uma mensagem
+// por arquivo JSON. As mensagens sobrevivem a reinicios da aplicacao, o que
+// e util em desenvolvimento. O diretorio deve ser usado por um unico
+// processo por vez.
+type FileQueue struct {
+	*MemoryQueue
+	dir string
+}
+
+func NewFileQueue(dir string, config MemoryQueueConfig) (*FileQueue, error) {
+	if config.Name == "" {
+		config.Name = dir
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("queue: failed to create %s: %w", dir, err)
+	}
+
+	q := &FileQueue{MemoryQueue: NewMemoryQueue(config), dir: dir}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	q.persist = q.write
+	q.remove = q.delete
+
+	return q, nil
+}
+
+func (q *FileQueue) load() error {
+	// Arquivos .tmp sao escritas interrompidas antes do rename; a versao
+	// anterior da mensagem, se existir, continua no .json.
+	stale, err := filepath.Glob(filepath.Join(q.dir, "*.json.tmp"))
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("queue: failed to remove %s: %w", path, err)
+		}
+	}
+
+	paths, err := filepath.Glob(filepath.Join(q.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("queue: failed to read %s: %w", path, err)
+		}
+		var m storedMessage
+		if err := json.Unmarshal(data, &m); err != nil {
+			return fmt.Errorf("queue: failed to decode %s: %w", path, err)
+		}
+
+		q.messages = append(q.messages, &m)
+		if m.Sequence > q.sequence {
+			q.sequence = m.Sequence
+		}
+		if q.config.FIFO && m.DeduplicationId != "" {
+			q.dedup[m.DeduplicationId] = dedupEntry{messageId: m.Id, sentAt: m.SentAt}
+		}
+	}
+
+	sort.Slice(q.messages, func(i, j int) bool {
+		return q.messages[i].Sequence < q.messages[j].Sequence
+	})
+	return nil
+}
+
+// write grava a mensagem em um arquivo temporario e o renomeia, para que uma
+// interrupcao no meio da escrita nao corrompa a mensagem. O arquivo e
+// sincronizado antes do rename e o diretorio depois dele, para que o rename
+// nao chegue ao disco antes do conteudo.
+func (q *FileQueue) write(m *storedMessage) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	path := q.path(m)
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("queue: failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("queue: failed to write %s: %w", path, err)
+	}
+	if err := syncDir(q.dir); err != nil {
+		return fmt.Errorf("queue: failed to sync %s: %w", q.dir, err)
+	}
+	return nil
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (q *FileQueue) delete(m *storedMessage) error {
+	if err := os.Remove(q.path(m)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("queue: failed to delete %s: %w", q.path(m), err)
+	}
+	return nil
+}
+
+func (q *FileQueue) path(m *storedMessage) string {
+	name := fmt.Sprintf("%020d-%s.json", m.Sequence, strings.ReplaceAll(m.Id, string(filepath.Separator), "_"))
+	return filepath.Join(q.dir, name)
+}
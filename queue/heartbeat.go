@@ -0,0 +1,75 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type HeartbeatConfig struct {
+	Extension int32         // segundos de visibilidade a cada renovacao; padrao 30
+	Interval  time.Duration // padrao metade da Extension
+	MaxTotal  time.Duration // limite total de extensao; padrao 12 horas (limite do SQS)
+}
+
+func (c *HeartbeatConfig) validate() {
+	if c.Extension <= 0 {
+		c.Extension = 30
+	}
+	if c.Interval <= 0 {
+		c.Interval = time.Duration(c.Extension) * time.Second / 2
+	}
+	if c.MaxTotal <= 0 {
+		c.MaxTotal = 12 * time.Hour
+	}
+}
+
+// StartHeartbeat renova periodicamente a visibilidade da mensagem enquanto ela
+// esta sendo processada, evitando que seja entregue a outro consumer. A
+// funcao devolvida encerra a renovacao e so retorna depois que nenhuma
+// chamada estiver em andamento, entao pode ser seguida de DeleteMessage.
+func (q *ToSqs) StartHeartbeat(ctx context.Context, receiptHandle *string, cfg HeartbeatConfig) (stop func()) {
+	return startHeartbeat(ctx, q, receiptHandle, cfg)
+}
+
+func startHeartbeat(ctx context.Context, consumer Consumer, receiptHandle *string, cfg HeartbeatConfig) (stop func()) {
+	cfg.validate()
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		started := time.Now()
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			extension := time.Duration(cfg.Extension) * time.Second
+			if remaining := cfg.MaxTotal - time.Since(started); extension > remaining {
+				extension = remaining
+			}
+			if extension < time.Second {
+				fmt.Println("Limite de extensao de visibilidade atingido...")
+				return
+			}
+
+			err := consumer.ChangeMessageVisibility(ctx, receiptHandle, int32(extension/time.Second))
+			if err != nil && ctx.Err() == nil {
+				fmt.Println("Erro ao estender visibilidade da mensagem:", err)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,125 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// visibilityRecorder e um Consumer que so registra as renovacoes.
+type visibilityRecorder struct {
+	mu       sync.Mutex
+	handles  []string
+	timeouts []int32
+	delay    time.Duration
+	inFlight atomic.Bool
+}
+
+func (r *visibilityRecorder) Consume(ctx context.Context, cfg ConsumerConfig) (<-chan types.Message, error) {
+	return nil, nil
+}
+
+func (r *visibilityRecorder) DeleteMessage(ctx context.Context, receiptHandle *string) error {
+	return nil
+}
+
+func (r *visibilityRecorder) ChangeMessageVisibility(ctx context.Context, receiptHandle *string, visibilityTimeout int32) error {
+	r.inFlight.Store(true)
+	defer r.inFlight.Store(false)
+	time.Sleep(r.delay)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handles = append(r.handles, aws.ToString(receiptHandle))
+	r.timeouts = append(r.timeouts, visibilityTimeout)
+	return nil
+}
+
+func (r *visibilityRecorder) calls() []int32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int32(nil), r.timeouts...)
+}
+
+func TestHeartbeatExtendsOnInterval(t *testing.T) {
+	consumer := &visibilityRecorder{}
+	stop := startHeartbeat(context.Background(), consumer, aws.String("h1"), HeartbeatConfig{Extension: 30, Interval: 20 * time.Millisecond})
+	waitFor(t, func() bool { return len(consumer.calls()) >= 3 })
+	stop()
+
+	for i, timeout := range consumer.calls() {
+		if timeout != 30 || consumer.handles[i] != "h1" {
+			t.Fatalf("call %d = %s/%d, want h1/30", i, consumer.handles[i], timeout)
+		}
+	}
+}
+
+func TestHeartbeatDefaultInterval(t *testing.T) {
+	cfg := HeartbeatConfig{Extension: 10}
+	cfg.validate()
+	if cfg.Interval != 5*time.Second || cfg.MaxTotal != 12*time.Hour {
+		t.Fatalf("config = %+v, want 5s interval and 12h MaxTotal", cfg)
+	}
+}
+
+func TestHeartbeatStopsAtMaxTotal(t *testing.T) {
+	consumer := &visibilityRecorder{}
+	stop := startHeartbeat(context.Background(), consumer, aws.String("h1"), HeartbeatConfig{
+		Extension: 30,
+		Interval:  20 * time.Millisecond,
+		MaxTotal:  1100 * time.Millisecond,
+	})
+	defer stop()
+
+	// Perto do limite a extensao e reduzida ao que resta, e abaixo de um
+	// segundo a renovacao para sozinha.
+	waitFor(t, func() bool { return len(consumer.calls()) > 0 })
+	if first := consumer.calls()[0]; first != 1 {
+		t.Fatalf("first extension = %d, want capped to 1 second", first)
+	}
+	time.Sleep(300 * time.Millisecond)
+	settled := len(consumer.calls())
+	time.Sleep(100 * time.Millisecond)
+	if got := len(consumer.calls()); got != settled {
+		t.Fatalf("extensions continued after MaxTotal: %d then %d", settled, got)
+	}
+}
+
+func TestHeartbeatStop(t *testing.T) {
+	consumer := &visibilityRecorder{delay: 50 * time.Millisecond}
+	stop := startHeartbeat(context.Background(), consumer, aws.String("h1"), HeartbeatConfig{Extension: 30, Interval: 10 * time.Millisecond})
+
+	// stop espera a renovacao em andamento terminar.
+	waitFor(t, consumer.inFlight.Load)
+	stop()
+	if consumer.inFlight.Load() {
+		t.Fatal("stop returned while an extension was in flight")
+	}
+
+	stopped := len(consumer.calls())
+	time.Sleep(100 * time.Millisecond)
+	if got := len(consumer.calls()); got != stopped {
+		t.Fatalf("extensions after stop: %d then %d", stopped, got)
+	}
+}
+
+func TestHeartbeatStopsWithContext(t *testing.T) {
+	consumer := &visibilityRecorder{}
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := startHeartbeat(ctx, consumer, aws.String("h1"), HeartbeatConfig{Extension: 30, Interval: 10 * time.Millisecond})
+	defer stop()
+
+	waitFor(t, func() bool { return len(consumer.calls()) > 0 })
+	cancel()
+	time.Sleep(30 * time.Millisecond)
+	canceled := len(consumer.calls())
+	time.Sleep(50 * time.Millisecond)
+	if got := len(consumer.calls()); got != canceled {
+		t.Fatalf("extensions after cancel: %d then %d", canceled, got)
+	}
+}
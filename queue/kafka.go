@@ -0,0 +1,568 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// HeaderMessageId carrega o id da mensagem nos headers do Kafka.
+const HeaderMessageId = "message-id"
+
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+	GroupId string // consumer group usado pelo Consume
+	// StartOffset define onde um grupo sem offset salvo comeca a ler:
+	// kafka.FirstOffset (padrao) ou kafka.LastOffset.
+	StartOffset  int64
+	BatchTimeout time.Duration // espera maxima para agrupar mensagens no envio; padrao 10ms
+}
+
+// ToKafka oferece sobre o Kafka a mesma interface do ToSqs. O messageGroupId
+// vira a chave da mensagem, garantindo ordem por chave dentro da particao, e
+// DeleteMessage confirma o offset no consumer group.
+type ToKafka struct {
+	config KafkaConfig
+	writer *kafka.Writer
+
+	mu        sync.Mutex
+	consumers map[string]*kafkaConsumer
+
+	// newReader permite substituir o reader do consumer group nos testes.
+	newReader func(config kafka.ReaderConfig) kafkaReader
+}
+
+type kafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// kafkaConsumer acompanha as mensagens entregues por um reader. Como o Kafka
+// nao reentrega mensagens individuais, a mensagem com erro e reentregue pelo
+// proprio consumer quando o prazo de ChangeMessageVisibility expira, contando
+// as tentativas por offset.
+type kafkaConsumer struct {
+	tag     string
+	topic   string
+	reader  kafkaReader
+	retry   chan types.Message
+	stopped chan struct{} // fechado quando o consumo e cancelado
+	onClose func()
+
+	mu         sync.Mutex
+	partitions map[int]*partitionOffsets
+	sequence   uint64
+	committing int
+	draining   bool
+	closed     bool
+}
+
+// partitionOffsets acompanha as mensagens entregues de uma particao. Como
+// confirmar um offset confirma todos os anteriores, so e confirmado o maior
+// offset cujos anteriores ja terminaram, mesmo com handlers concorrentes.
+type partitionOffsets struct {
+	last     int64 // maior offset lido; ler um offset menor indica reatribuicao
+	pending  []int64
+	done     map[int64]bool
+	inflight map[int64]*kafkaDelivery
+}
+
+type kafkaDelivery struct {
+	message  kafka.Message
+	attempts int
+	sequence uint64 // identifica a entrega atual; receipt handles antigos sao rejeitados
+	timer    *time.Timer
+}
+
+func NewToKafka(config KafkaConfig) *ToKafka {
+	if config.StartOffset == 0 {
+		config.StartOffset = kafka.FirstOffset
+	}
+	if config.BatchTimeout <= 0 {
+		config.BatchTimeout = 10 * time.Millisecond
+	}
+
+	return &ToKafka{
+		config: config,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(config.Brokers...),
+			Topic:                  config.Topic,
+			Balancer:               &kafka.Hash{},
+			BatchTimeout:           config.BatchTimeout,
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		consumers: map[string]*kafkaConsumer{},
+		newReader: func(config kafka.ReaderConfig) kafkaReader {
+			return kafka.NewReader(config)
+		},
+	}
+}
+
+// SendMessage publica a mensagem com messageGroupId como chave: mensagens com
+// a mesma chave vao para a mesma particao. Os atributos viram headers.
+func (k *ToKafka) SendMessage(message []byte, messageGroupId string, opts ...SendOption) (*sqs.SendMessageOutput, error) {
+	var options sendOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.delaySeconds > 0 {
+		return nil, fmt.Errorf("queue: kafka does not support DelaySeconds")
+	}
+
+	messageId := options.deduplicationId
+	if messageId == "" {
+		messageId = uuid.New().String()
+	}
+
+	msg := kafka.Message{
+		Value:   message,
+		Headers: []kafka.Header{{Key: HeaderMessageId, Value: []byte(messageId)}},
+	}
+	if messageGroupId != "" {
+		msg.Key = []byte(messageGroupId)
+	}
+	for name, value := range options.attributes {
+		if value.StringValue != nil {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: name, Value: []byte(*value.StringValue)})
+		} else if value.BinaryValue != nil {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: name, Value: value.BinaryValue})
+		}
+	}
+
+	if err := k.writer.WriteMessages(context.TODO(), msg); err != nil {
+		return nil, err
+	}
+	return &sqs.SendMessageOutput{MessageId: aws.String(messageId)}, nil
+}
+
+// Consume entra no consumer group e entrega as mensagens sem confirmar o
+// offset. Ao cancelar o contexto a leitura para, mas o reader continua no
+// grupo ate os handlers em andamento confirmarem seus offsets; depois ele sai
+// do grupo e dispara o rebalanceamento.
+func (k *ToKafka) Consume(ctx context.Context, cfg ConsumerConfig) (<-chan types.Message, error) {
+	cfg.validate()
+	if k.config.GroupId == "" {
+		return nil, fmt.Errorf("queue: kafka consumer requires a GroupId")
+	}
+
+	consumerTag := uuid.New().String()
+	consumer := &kafkaConsumer{
+		tag:   consumerTag,
+		topic: k.config.Topic,
+		reader: k.newReader(kafka.ReaderConfig{
+			Brokers:     k.config.Brokers,
+			Topic:       k.config.Topic,
+			GroupID:     k.config.GroupId,
+			StartOffset: k.config.StartOffset,
+			MaxWait:     time.Duration(cfg.WaitTimeSeconds) * time.Second,
+		}),
+		retry:      make(chan types.Message),
+		stopped:    make(chan struct{}),
+		partitions: map[int]*partitionOffsets{},
+		onClose: func() {
+			k.mu.Lock()
+			delete(k.consumers, consumerTag)
+			k.mu.Unlock()
+		},
+	}
+
+	k.mu.Lock()
+	k.consumers[consumerTag] = consumer
+	k.mu.Unlock()
+
+	// FetchMessage bloqueia, entao a leitura roda separada das reentregas.
+	fetched := make(chan kafka.Message)
+	go func() {
+		defer close(fetched)
+
+		for {
+			m, err := consumer.reader.FetchMessage(ctx)
+			if err != nil {
+				// io.EOF indica que o reader foi fechado.
+				if ctx.Err() != nil || errors.Is(err, io.EOF) {
+					return
+				}
+				fmt.Println("Erro ao receber mensagem:", err)
+				select {
+				case <-time.After(cfg.PollInterval):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			select {
+			case fetched <- m:
+			case <-consumer.stopped:
+				return
+			}
+		}
+	}()
+
+	msgCh := make(chan types.Message, cfg.BufferSize)
+
+	go func() {
+		defer close(msgCh)
+		defer consumer.stop()
+
+		for {
+			var msg types.Message
+			select {
+			case m, ok := <-fetched:
+				if !ok {
+					fmt.Println("Consumer finalizado...")
+					return
+				}
+				msg = consumer.deliver(m)
+			case msg = <-consumer.retry:
+			case <-ctx.Done():
+				fmt.Println("Consumer finalizado...")
+				return
+			}
+
+			select {
+			case msgCh <- msg:
+			case <-ctx.Done():
+				consumer.release(msg.ReceiptHandle)
+				fmt.Println("Consumer finalizado...")
+				return
+			}
+		}
+	}()
+	return msgCh, nil
+}
+
+// DeleteMessage marca a mensagem como processada e confirma o offset da
+// particao ate a ultima mensagem processada sem lacunas.
+func (k *ToKafka) DeleteMessage(ctx context.Context, receiptHandle *string) error {
+	consumer, partition, offset, sequence, err := k.delivery(receiptHandle)
+	if err != nil {
+		return err
+	}
+	return consumer.ack(ctx, partition, offset, sequence)
+}
+
+// ChangeMessageVisibility emula o visibility timeout do SQS: a mensagem e
+// reentregue por este consumer apos visibilityTimeout segundos, ou na hora
+// com timeout zero, com ApproximateReceiveCount incrementado. Cada chamada
+// substitui o prazo anterior e DeleteMessage o cancela. Enquanto a mensagem
+// nao for confirmada, os offsets seguintes da particao tambem nao sao.
+func (k *ToKafka) ChangeMessageVisibility(ctx context.Context, receiptHandle *string, visibilityTimeout int32) error {
+	consumer, partition, offset, sequence, err := k.delivery(receiptHandle)
+	if err != nil {
+		return err
+	}
+	return consumer.changeVisibility(partition, offset, sequence, time.Duration(visibilityTimeout)*time.Second)
+}
+
+// Close encerra o producer e sai do consumer group. Os canais dos consumers
+// sao fechados e as mensagens sem confirmacao voltam a ser lidas pelo grupo.
+func (k *ToKafka) Close() error {
+	k.mu.Lock()
+	consumers := make([]*kafkaConsumer, 0, len(k.consumers))
+	for tag, consumer := range k.consumers {
+		consumers = append(consumers, consumer)
+		delete(k.consumers, tag)
+	}
+	k.mu.Unlock()
+
+	var errs []string
+	for _, consumer := range consumers {
+		if err := consumer.close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if err := k.writer.Close(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("queue: failed to close kafka: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (k *ToKafka) queueName() string {
+	return k.config.Topic
+}
+
+func (k *ToKafka) withoutVisibilityTimeout() {}
+
+// O receipt handle identifica o consumer, a particao, o offset e a entrega
+// atual da mensagem.
+func (k *ToKafka) delivery(receiptHandle *string) (*kafkaConsumer, int, int64, uint64, error) {
+	consumerTag, partition, offset, sequence, err := parseKafkaHandle(receiptHandle)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	k.mu.Lock()
+	consumer := k.consumers[consumerTag]
+	k.mu.Unlock()
+	if consumer == nil {
+		return nil, 0, 0, 0, ErrInvalidReceiptHandle
+	}
+	return consumer, partition, offset, sequence, nil
+}
+
+func parseKafkaHandle(receiptHandle *string) (string, int, int64, uint64, error) {
+	parts := strings.Split(aws.ToString(receiptHandle), ":")
+	if len(parts) != 4 {
+		return "", 0, 0, 0, ErrInvalidReceiptHandle
+	}
+	partition, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, 0, 0, ErrInvalidReceiptHandle
+	}
+	offset, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, 0, 0, ErrInvalidReceiptHandle
+	}
+	sequence, err := strconv.ParseUint(parts[3], 10, 64)
+	if err != nil {
+		return "", 0, 0, 0, ErrInvalidReceiptHandle
+	}
+	return parts[0], partition, offset, sequence, nil
+}
+
+// deliver registra a mensagem lida do reader como primeira tentativa.
+func (c *kafkaConsumer) deliver(m kafka.Message) types.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := c.partitions[m.Partition]
+	if p != nil && m.Offset <= p.last {
+		// O reader so volta a ler um offset depois que a particao foi
+		// reatribuida; o estado anterior nao vale mais.
+		p.reset()
+		p = nil
+	}
+	if p == nil {
+		p = &partitionOffsets{done: map[int64]bool{}, inflight: map[int64]*kafkaDelivery{}}
+		c.partitions[m.Partition] = p
+	}
+	p.last = m.Offset
+	p.pending = append(p.pending, m.Offset)
+
+	c.sequence++
+	d := &kafkaDelivery{message: m, attempts: 1, sequence: c.sequence}
+	p.inflight[m.Offset] = d
+	return kafkaMessage(c.tag, m, d.attempts, d.sequence)
+}
+
+// current devolve a entrega identificada pelo receipt handle, se ela ainda
+// for a entrega atual do offset. Deve ser chamado com c.mu travado.
+func (c *kafkaConsumer) current(partition int, offset int64, sequence uint64) (*partitionOffsets, *kafkaDelivery) {
+	p := c.partitions[partition]
+	if p == nil {
+		return nil, nil
+	}
+	d := p.inflight[offset]
+	if d == nil || d.sequence != sequence {
+		return nil, nil
+	}
+	return p, d
+}
+
+// ack confirma o offset da particao ate a ultima mensagem processada sem
+// lacunas e fecha o reader de um consumer cancelado que ficou ocioso.
+func (c *kafkaConsumer) ack(ctx context.Context, partition int, offset int64, sequence uint64) error {
+	c.mu.Lock()
+	p, d := c.current(partition, offset, sequence)
+	if d == nil {
+		c.mu.Unlock()
+		return ErrInvalidReceiptHandle
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	delete(p.inflight, offset)
+	p.done[offset] = true
+
+	// Apos uma reentrega os offsets podem terminar fora de ordem.
+	sort.Slice(p.pending, func(i, j int) bool { return p.pending[i] < p.pending[j] })
+
+	commit, ok := int64(0), false
+	for len(p.pending) > 0 && p.done[p.pending[0]] {
+		commit, ok = p.pending[0], true
+		delete(p.done, p.pending[0])
+		p.pending = p.pending[1:]
+	}
+	if ok {
+		c.committing++
+	}
+	c.mu.Unlock()
+
+	var err error
+	if ok {
+		err = c.reader.CommitMessages(ctx, kafka.Message{Topic: c.topic, Partition: partition, Offset: commit})
+
+		c.mu.Lock()
+		c.committing--
+		c.mu.Unlock()
+	}
+	c.closeIfDrained()
+	return err
+}
+
+func (c *kafkaConsumer) changeVisibility(partition int, offset int64, sequence uint64, timeout time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, d := c.current(partition, offset, sequence)
+	if d == nil {
+		return ErrInvalidReceiptHandle
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timer = time.AfterFunc(timeout, func() {
+		c.redeliver(partition, offset, sequence)
+	})
+	return nil
+}
+
+// redeliver entrega de novo a mensagem cujo prazo expirou, como nova
+// tentativa. Se o consumo ja foi cancelado, a mensagem fica sem confirmacao
+// e volta a ser lida pelo grupo.
+func (c *kafkaConsumer) redeliver(partition int, offset int64, sequence uint64) {
+	c.mu.Lock()
+	_, d := c.current(partition, offset, sequence)
+	if d == nil {
+		c.mu.Unlock()
+		return
+	}
+	c.sequence++
+	d.timer = nil
+	d.attempts++
+	d.sequence = c.sequence
+	msg := kafkaMessage(c.tag, d.message, d.attempts, d.sequence)
+	c.mu.Unlock()
+
+	select {
+	case c.retry <- msg:
+	case <-c.stopped:
+		c.release(msg.ReceiptHandle)
+	}
+}
+
+// release abandona a entrega sem confirmar o offset, que segura os offsets
+// seguintes da particao ate o reader ser fechado.
+func (c *kafkaConsumer) release(receiptHandle *string) {
+	_, partition, offset, sequence, err := parseKafkaHandle(receiptHandle)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	p, d := c.current(partition, offset, sequence)
+	if d != nil {
+		if d.timer != nil {
+			d.timer.Stop()
+		}
+		delete(p.inflight, offset)
+	}
+	c.mu.Unlock()
+	c.closeIfDrained()
+}
+
+// stop marca o consumo como cancelado; o reader e fechado quando nao houver
+// mais mensagens em andamento.
+func (c *kafkaConsumer) stop() {
+	c.mu.Lock()
+	c.draining = true
+	c.mu.Unlock()
+
+	close(c.stopped)
+	c.closeIfDrained()
+}
+
+func (c *kafkaConsumer) closeIfDrained() {
+	c.mu.Lock()
+	if !c.draining || c.closed || c.committing > 0 {
+		c.mu.Unlock()
+		return
+	}
+	for _, p := range c.partitions {
+		if len(p.inflight) > 0 {
+			c.mu.Unlock()
+			return
+		}
+	}
+	c.mu.Unlock()
+
+	if err := c.close(); err != nil {
+		fmt.Println("Erro ao fechar consumer:", err)
+	}
+}
+
+// close sai do consumer group e descarta o estado das particoes, que podem
+// ser atribuidas a outro consumer.
+func (c *kafkaConsumer) close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	for _, p := range c.partitions {
+		p.reset()
+	}
+	c.partitions = map[int]*partitionOffsets{}
+	c.mu.Unlock()
+
+	c.onClose()
+	return c.reader.Close()
+}
+
+// reset cancela as reentregas agendadas da particao.
+func (p *partitionOffsets) reset() {
+	for _, d := range p.inflight {
+		if d.timer != nil {
+			d.timer.Stop()
+		}
+	}
+}
+
+func kafkaMessage(consumerTag string, m kafka.Message, attempts int, sequence uint64) types.Message {
+	attributes := map[string]string{
+		string(types.MessageSystemAttributeNameApproximateReceiveCount): strconv.Itoa(attempts),
+		string(types.MessageSystemAttributeNameSentTimestamp):           strconv.FormatInt(m.Time.UnixMilli(), 10),
+		string(types.MessageSystemAttributeNameSequenceNumber):          strconv.FormatInt(m.Offset, 10),
+	}
+	if len(m.Key) > 0 {
+		attributes[string(types.MessageSystemAttributeNameMessageGroupId)] = string(m.Key)
+	}
+
+	messageId := fmt.Sprintf("%s-%d-%d", m.Topic, m.Partition, m.Offset)
+	messageAttributes := map[string]types.MessageAttributeValue{}
+	for _, header := range m.Headers {
+		if header.Key == HeaderMessageId {
+			messageId = string(header.Value)
+			continue
+		}
+		messageAttributes[header.Key] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(string(header.Value))}
+	}
+
+	return types.Message{
+		MessageId:         aws.String(messageId),
+		ReceiptHandle:     aws.String(fmt.Sprintf("%s:%d:%d:%d", consumerTag, m.Partition, m.Offset, sequence)),
+		Body:              aws.String(string(m.Value)),
+		Attributes:        attributes,
+		MessageAttributes: messageAttributes,
+	}
+}
@@ -0,0 +1,269 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeReader entrega as mensagens de msgs e registra os offsets confirmados,
+// simulando um reader de consumer group sem broker.
+type fakeReader struct {
+	msgs chan kafka.Message
+
+	mu        sync.Mutex
+	committed map[int]int64
+	closed    bool
+	done      chan struct{}
+}
+
+func newFakeReader(msgs ...kafka.Message) *fakeReader {
+	r := &fakeReader{msgs: make(chan kafka.Message, len(msgs)+10), committed: map[int]int64{}, done: make(chan struct{})}
+	for _, m := range msgs {
+		r.msgs <- m
+	}
+	return r
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	case <-r.done:
+		return kafka.Message{}, io.EOF
+	case m := <-r.msgs:
+		return m, nil
+	}
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return io.ErrClosedPipe
+	}
+	for _, m := range msgs {
+		r.committed[m.Partition] = m.Offset
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		close(r.done)
+	}
+	return nil
+}
+
+func (r *fakeReader) state() (map[int]int64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	committed := map[int]int64{}
+	for partition, offset := range r.committed {
+		committed[partition] = offset
+	}
+	return committed, r.closed
+}
+
+func newTestKafka(reader *fakeReader) *ToKafka {
+	k := NewToKafka(KafkaConfig{Topic: "pedidos", GroupId: "grupo"})
+	k.newReader = func(kafka.ReaderConfig) kafkaReader { return reader }
+	return k
+}
+
+func kafkaMsg(partition int, offset int64, body string) kafka.Message {
+	return kafka.Message{Topic: "pedidos", Partition: partition, Offset: offset, Value: []byte(body), Time: time.Now()}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestKafkaProcessorRetriesInPlaceAndMovesToDLQ(t *testing.T) {
+	reader := newFakeReader(kafkaMsg(0, 10, "falha"), kafkaMsg(0, 11, "ok"))
+	k := newTestKafka(reader)
+	dlq := NewMemoryQueue(MemoryQueueConfig{})
+
+	var mu sync.Mutex
+	counts := map[string][]int{}
+	processor := NewProcessor(k, ProcessorConfig{
+		Workers:    2,
+		RetryDelay: RetryImmediately,
+		DeadLetter: &DeadLetterConfig{Queue: dlq, MaxAttempts: 3},
+	}, func(ctx context.Context, msg types.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		counts[aws.ToString(msg.Body)] = append(counts[aws.ToString(msg.Body)], ReceiveCount(msg))
+		if aws.ToString(msg.Body) == "falha" {
+			return errors.New("handler falhou")
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- processor.Run(ctx) }()
+
+	waitFor(t, func() bool { return dlq.Len() == 1 })
+	waitFor(t, func() bool {
+		committed, _ := reader.state()
+		return committed[0] == 11
+	})
+
+	mu.Lock()
+	if got := counts["falha"]; len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("receive counts = %v, want [1 2 3]", got)
+	}
+	mu.Unlock()
+
+	cancel()
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		_, closed := reader.state()
+		return closed
+	})
+}
+
+func TestKafkaChangeMessageVisibilityDelaysRedelivery(t *testing.T) {
+	reader := newFakeReader(kafkaMsg(0, 0, "a"))
+	k := newTestKafka(reader)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgCh, err := k.Consume(ctx, ConsumerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := <-msgCh
+	if err := k.ChangeMessageVisibility(ctx, first.ReceiptHandle, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-msgCh:
+		t.Fatal("message redelivered before the visibility timeout")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	second := <-msgCh
+	if ReceiveCount(second) != 2 {
+		t.Fatalf("ReceiveCount = %d, want 2", ReceiveCount(second))
+	}
+	if err := k.DeleteMessage(ctx, first.ReceiptHandle); !errors.Is(err, ErrInvalidReceiptHandle) {
+		t.Fatalf("stale handle err = %v, want ErrInvalidReceiptHandle", err)
+	}
+	if err := k.DeleteMessage(ctx, second.ReceiptHandle); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKafkaCommitsOnlyContiguousOffsets(t *testing.T) {
+	reader := newFakeReader(kafkaMsg(0, 0, "a"), kafkaMsg(0, 1, "b"), kafkaMsg(0, 2, "c"))
+	k := newTestKafka(reader)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgCh, _ := k.Consume(ctx, ConsumerConfig{})
+	a, b, c := <-msgCh, <-msgCh, <-msgCh
+
+	k.DeleteMessage(ctx, b.ReceiptHandle)
+	k.DeleteMessage(ctx, c.ReceiptHandle)
+	if committed, _ := reader.state(); len(committed) != 0 {
+		t.Fatalf("committed %v before offset 0 finished", committed)
+	}
+	k.DeleteMessage(ctx, a.ReceiptHandle)
+	if committed, _ := reader.state(); committed[0] != 2 {
+		t.Fatalf("committed = %v, want offset 2", committed)
+	}
+}
+
+func TestKafkaShutdownWaitsForInflightOffsets(t *testing.T) {
+	reader := newFakeReader(kafkaMsg(0, 0, "a"))
+	k := newTestKafka(reader)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	msgCh, _ := k.Consume(ctx, ConsumerConfig{})
+	msg := <-msgCh
+	cancel()
+
+	if _, ok := <-msgCh; ok {
+		t.Fatal("msgCh should close after cancel")
+	}
+	if _, closed := reader.state(); closed {
+		t.Fatal("reader closed before the in-flight offset was committed")
+	}
+
+	if err := k.DeleteMessage(context.Background(), msg.ReceiptHandle); err != nil {
+		t.Fatal(err)
+	}
+	committed, closed := reader.state()
+	if committed[0] != 0 || !closed {
+		t.Fatalf("committed = %v, closed = %v; want commit then close", committed, closed)
+	}
+	if err := k.DeleteMessage(context.Background(), msg.ReceiptHandle); !errors.Is(err, ErrInvalidReceiptHandle) {
+		t.Fatalf("err after close = %v, want ErrInvalidReceiptHandle", err)
+	}
+}
+
+func TestKafkaCloseStopsConsumer(t *testing.T) {
+	reader := newFakeReader()
+	k := newTestKafka(reader)
+
+	msgCh, _ := k.Consume(context.Background(), ConsumerConfig{})
+	if err := k.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case _, ok := <-msgCh:
+		if ok {
+			t.Fatal("unexpected message")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("msgCh not closed after Close")
+	}
+}
+
+func TestKafkaResetsPartitionAfterReassignment(t *testing.T) {
+	reader := newFakeReader(kafkaMsg(0, 5, "a"), kafkaMsg(0, 6, "b"))
+	k := newTestKafka(reader)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgCh, _ := k.Consume(ctx, ConsumerConfig{})
+	<-msgCh
+	old := <-msgCh
+
+	// Reatribuida, a particao volta a ser lida desde o ultimo commit.
+	reader.msgs <- kafkaMsg(0, 5, "a")
+	reader.msgs <- kafkaMsg(0, 6, "b")
+	first, second := <-msgCh, <-msgCh
+
+	if err := k.DeleteMessage(ctx, old.ReceiptHandle); !errors.Is(err, ErrInvalidReceiptHandle) {
+		t.Fatalf("handle from before the reassignment: err = %v", err)
+	}
+	k.DeleteMessage(ctx, first.ReceiptHandle)
+	k.DeleteMessage(ctx, second.ReceiptHandle)
+	if committed, _ := reader.state(); committed[0] != 6 {
+		t.Fatalf("committed = %v, want offset 6", committed)
+	}
+}
@@ -0,0 +1,198 @@
+package queue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/google/uuid"
+	"github.com/simpplify-org/GO-data-connector-lib/bucket"
+)
+
+// Formato do Amazon SQS Extended Client, para interoperar com os clients
+// oficiais de Java e Python.
+const (
+	AttributeExtendedPayloadSize = "ExtendedPayloadSize"
+	legacyPayloadSizeAttribute   = "SQSLargePayloadSize"
+	payloadPointerClass          = "software.amazon.payloadoffloading.PayloadS3Pointer"
+	receiptBucketMarker          = "-..s3BucketName..-"
+	receiptKeyMarker             = "-..s3Key..-"
+)
+
+// AttributePayloadError e adicionado aos atributos de sistema da mensagem
+// cujo corpo nao pode ser buscado no S3. A mensagem e entregue com o ponteiro
+// no corpo para que siga o fluxo de retry e DLQ.
+const AttributePayloadError = "PayloadError"
+
+var ErrPayloadUnavailable = errors.New("queue: large payload unavailable")
+
+// PayloadError devolve um erro que envolve ErrPayloadUnavailable quando o
+// corpo da mensagem nao pode ser buscado no S3. O Processor trata esse erro
+// como falha do handler; quem consome o canal diretamente deve verifica-lo.
+func PayloadError(msg types.Message) error {
+	reason, ok := msg.Attributes[AttributePayloadError]
+	if !ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrPayloadUnavailable, reason)
+}
+
+// markPayloadError registra a falha em uma copia dos atributos de sistema.
+func markPayloadError(msg *types.Message, err error) {
+	attributes := map[string]string{}
+	for name, value := range msg.Attributes {
+		attributes[name] = value
+	}
+	attributes[AttributePayloadError] = err.Error()
+	msg.Attributes = attributes
+}
+
+// LargePayloadConfig habilita o envio de mensagens grandes pelo S3: o corpo
+// e gravado no bucket e a fila recebe apenas um ponteiro para o objeto.
+type LargePayloadConfig struct {
+	Bucket        *bucket.ToS3
+	Threshold     int    // tamanho a partir do qual o corpo vai para o S3; padrao 256 KB
+	AlwaysOffload bool   // envia todos os corpos pelo S3
+	KeyPrefix     string // prefixo das chaves criadas no bucket
+	// DeletePayload remove o objeto do S3 quando a mensagem e deletada.
+	DeletePayload bool
+}
+
+type payloadPointer struct {
+	S3BucketName string `json:"s3BucketName"`
+	S3Key        string `json:"s3Key"`
+}
+
+// offload grava o corpo no S3 quando necessario e devolve o corpo a ser
+// enviado para a fila.
+func (q *ToSqs) offload(ctx context.Context, body []byte, params *sendParams) ([]byte, error) {
+	cfg := q.LargePayload
+	if cfg == nil || cfg.Bucket == nil {
+		return body, nil
+	}
+
+	threshold := cfg.Threshold
+	if threshold <= 0 {
+		threshold = maxBatchBytes
+	}
+	if !cfg.AlwaysOffload && messageSize(body, params.attributes) <= threshold {
+		return body, nil
+	}
+
+	// O ponteiro tem uma chave aleatoria; em filas FIFO o id de deduplicacao
+	// precisa vir do corpo original para que envios iguais sejam descartados.
+	if params.deduplicationId == nil && q.isFifo() {
+		hash := sha256.Sum256(body)
+		params.deduplicationId = aws.String(hex.EncodeToString(hash[:]))
+	}
+
+	pointer := payloadPointer{S3BucketName: cfg.Bucket.BucketName, S3Key: cfg.KeyPrefix + uuid.New().String()}
+	if err := cfg.Bucket.UploadBytes(ctx, pointer.S3Key, body); err != nil {
+		return nil, fmt.Errorf("failed to store large payload in s3: %w", err)
+	}
+
+	attributes := map[string]types.MessageAttributeValue{}
+	for name, value := range params.attributes {
+		attributes[name] = value
+	}
+	attributes[AttributeExtendedPayloadSize] = types.MessageAttributeValue{
+		DataType:    aws.String("Number"),
+		StringValue: aws.String(strconv.Itoa(len(body))),
+	}
+	params.attributes = attributes
+
+	return json.Marshal([]interface{}{payloadPointerClass, pointer})
+}
+
+// resolvePayload substitui o ponteiro pelo corpo gravado no S3 e guarda a
+// localizacao do objeto no receipt handle, como o Extended Client faz, para
+// que DeleteMessage possa remover o objeto.
+func (q *ToSqs) resolvePayload(ctx context.Context, msg *types.Message) error {
+	if q.LargePayload == nil || q.LargePayload.Bucket == nil {
+		return nil
+	}
+	if _, ok := msg.MessageAttributes[AttributeExtendedPayloadSize]; !ok {
+		if _, ok := msg.MessageAttributes[legacyPayloadSizeAttribute]; !ok {
+			return nil
+		}
+	}
+
+	pointer, err := parsePointer(aws.ToString(msg.Body))
+	if err != nil {
+		return err
+	}
+	if pointer.S3BucketName != q.LargePayload.Bucket.BucketName {
+		return fmt.Errorf("large payload stored in bucket %q, configured bucket is %q", pointer.S3BucketName, q.LargePayload.Bucket.BucketName)
+	}
+
+	body, err := q.LargePayload.Bucket.DownloadBytes(ctx, pointer.S3Key)
+	if err != nil {
+		return fmt.Errorf("failed to fetch large payload from s3: %w", err)
+	}
+
+	attributes := map[string]types.MessageAttributeValue{}
+	for name, value := range msg.MessageAttributes {
+		if name != AttributeExtendedPayloadSize && name != legacyPayloadSizeAttribute {
+			attributes[name] = value
+		}
+	}
+
+	msg.Body = aws.String(string(body))
+	msg.MessageAttributes = attributes
+	msg.ReceiptHandle = aws.String(receiptBucketMarker + pointer.S3BucketName + receiptBucketMarker +
+		receiptKeyMarker + pointer.S3Key + receiptKeyMarker + aws.ToString(msg.ReceiptHandle))
+	return nil
+}
+
+func parsePointer(body string) (payloadPointer, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(body), &raw); err != nil || len(raw) != 2 {
+		return payloadPointer{}, fmt.Errorf("invalid large payload pointer")
+	}
+
+	var pointer payloadPointer
+	if err := json.Unmarshal(raw[1], &pointer); err != nil || pointer.S3BucketName == "" || pointer.S3Key == "" {
+		return payloadPointer{}, fmt.Errorf("invalid large payload pointer")
+	}
+	return pointer, nil
+}
+
+// splitReceiptHandle separa o receipt handle original do SQS da localizacao
+// do objeto adicionada por resolvePayload.
+func splitReceiptHandle(handle *string) (*string, *payloadPointer) {
+	value := aws.ToString(handle)
+	if !strings.HasPrefix(value, receiptBucketMarker) {
+		return handle, nil
+	}
+
+	parts := strings.SplitN(value[len(receiptBucketMarker):], receiptBucketMarker, 2)
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], receiptKeyMarker) {
+		return handle, nil
+	}
+	rest := strings.SplitN(parts[1][len(receiptKeyMarker):], receiptKeyMarker, 2)
+	if len(rest) != 2 {
+		return handle, nil
+	}
+
+	return aws.String(rest[1]), &payloadPointer{S3BucketName: parts[0], S3Key: rest[0]}
+}
+
+func (q *ToSqs) deletePayload(ctx context.Context, pointer *payloadPointer) error {
+	if pointer == nil || q.LargePayload == nil || q.LargePayload.Bucket == nil || !q.LargePayload.DeletePayload {
+		return nil
+	}
+	if pointer.S3BucketName != q.LargePayload.Bucket.BucketName {
+		return nil
+	}
+	if err := q.LargePayload.Bucket.DeleteFile(ctx, pointer.S3Key); err != nil {
+		return fmt.Errorf("failed to delete large payload from s3: %w", err)
+	}
+	return nil
+}
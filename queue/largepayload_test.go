@@ -0,0 +1,244 @@
+package queue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/simpplify-org/GO-data-connector-lib/bucket"
+)
+
+// fakeS3 guarda os objetos em memoria, respondendo em path-style.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *bucket.ToS3) {
+	t.Helper()
+	fake := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/payloads/")
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			fake.objects[key] = body
+		case http.MethodGet:
+			body, ok := fake.objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `<Error><Code>NoSuchKey</Code></Error>`)
+				return
+			}
+			w.Write(body)
+		case http.MethodDelete:
+			delete(fake.objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := aws.Config{
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("test", "test", ""),
+		BaseEndpoint: aws.String(server.URL),
+	}
+	return fake, bucket.NewToS3FromConfig(cfg, "payloads")
+}
+
+func (f *fakeS3) object(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, ok := f.objects[key]
+	return body, ok
+}
+
+func (f *fakeS3) len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.objects)
+}
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		ok   bool
+	}{
+		{"valid", `["` + payloadPointerClass + `",{"s3BucketName":"b","s3Key":"k"}]`, true},
+		{"not json", `hello`, false},
+		{"object", `{"s3BucketName":"b","s3Key":"k"}`, false},
+		{"single element", `["` + payloadPointerClass + `"]`, false},
+		{"missing key", `["` + payloadPointerClass + `",{"s3BucketName":"b"}]`, false},
+		{"empty bucket", `["` + payloadPointerClass + `",{"s3BucketName":"","s3Key":"k"}]`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pointer, err := parsePointer(tt.body)
+			if (err == nil) != tt.ok {
+				t.Fatalf("err = %v, want ok = %v", err, tt.ok)
+			}
+			if tt.ok && (pointer.S3BucketName != "b" || pointer.S3Key != "k") {
+				t.Fatalf("pointer = %+v", pointer)
+			}
+		})
+	}
+}
+
+func TestSplitReceiptHandle(t *testing.T) {
+	marked := receiptBucketMarker + "b" + receiptBucketMarker + receiptKeyMarker + "pasta/k" + receiptKeyMarker + "handle-original"
+
+	tests := []struct {
+		name    string
+		handle  string
+		want    string
+		pointer *payloadPointer
+	}{
+		{"with markers", marked, "handle-original", &payloadPointer{S3BucketName: "b", S3Key: "pasta/k"}},
+		{"without markers", "handle-original", "handle-original", nil},
+		{"bucket marker only", receiptBucketMarker + "b" + receiptBucketMarker + "handle", receiptBucketMarker + "b" + receiptBucketMarker + "handle", nil},
+		{"unterminated key", receiptBucketMarker + "b" + receiptBucketMarker + receiptKeyMarker + "k", receiptBucketMarker + "b" + receiptBucketMarker + receiptKeyMarker + "k", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handle, pointer := splitReceiptHandle(aws.String(tt.handle))
+			if aws.ToString(handle) != tt.want {
+				t.Fatalf("handle = %q, want %q", aws.ToString(handle), tt.want)
+			}
+			if (pointer == nil) != (tt.pointer == nil) || (pointer != nil && *pointer != *tt.pointer) {
+				t.Fatalf("pointer = %+v, want %+v", pointer, tt.pointer)
+			}
+		})
+	}
+}
+
+func TestOffloadThreshold(t *testing.T) {
+	fake, s3 := newFakeS3(t)
+	q := NewToSqs("test", "test", "us-east-1", "https://sqs.us-east-1.amazonaws.com/000000000000/pedidos")
+	q.LargePayload = &LargePayloadConfig{Bucket: s3, Threshold: 100, KeyPrefix: "pedidos/"}
+
+	atThreshold := sendParams{}
+	body, err := q.offload(context.Background(), []byte(strings.Repeat("x", 100)), &atThreshold)
+	if err != nil || len(body) != 100 || fake.len() != 0 {
+		t.Fatalf("body of threshold size offloaded: len = %d, err = %v", len(body), err)
+	}
+
+	overThreshold := sendParams{}
+	payload := []byte(strings.Repeat("x", 101))
+	body, err = q.offload(context.Background(), payload, &overThreshold)
+	if err != nil {
+		t.Fatalf("offload: %v", err)
+	}
+	pointer, err := parsePointer(string(body))
+	if err != nil || pointer.S3BucketName != "payloads" || !strings.HasPrefix(pointer.S3Key, "pedidos/") {
+		t.Fatalf("body = %s, want pointer to the payloads bucket", body)
+	}
+	if stored, ok := fake.object(pointer.S3Key); !ok || string(stored) != string(payload) {
+		t.Fatalf("stored object = %q, want the payload", stored)
+	}
+	if size := aws.ToString(overThreshold.attributes[AttributeExtendedPayloadSize].StringValue); size != "101" {
+		t.Fatalf("%s = %q, want 101", AttributeExtendedPayloadSize, size)
+	}
+
+	// Atributos contam no tamanho, como no SQS.
+	withAttributes := sendParams{attributes: map[string]types.MessageAttributeValue{
+		"tipo": {DataType: aws.String("String"), StringValue: aws.String("pedido")},
+	}}
+	body, _ = q.offload(context.Background(), []byte(strings.Repeat("x", 90)), &withAttributes)
+	if _, err := parsePointer(string(body)); err != nil {
+		t.Fatal("body under the threshold with attributes over it was not offloaded")
+	}
+}
+
+func TestResolvePayloadRoundTrip(t *testing.T) {
+	fake, s3 := newFakeS3(t)
+	q := NewToSqs("test", "test", "us-east-1", "https://sqs.us-east-1.amazonaws.com/000000000000/pedidos")
+	q.LargePayload = &LargePayloadConfig{Bucket: s3, AlwaysOffload: true, DeletePayload: true}
+
+	params := sendParams{}
+	body, err := q.offload(context.Background(), []byte("payload"), &params)
+	if err != nil {
+		t.Fatalf("offload: %v", err)
+	}
+
+	msg := types.Message{
+		Body:              aws.String(string(body)),
+		ReceiptHandle:     aws.String("handle-original"),
+		MessageAttributes: params.attributes,
+	}
+	if err := q.resolvePayload(context.Background(), &msg); err != nil {
+		t.Fatalf("resolvePayload: %v", err)
+	}
+	if aws.ToString(msg.Body) != "payload" {
+		t.Fatalf("body = %q, want payload", aws.ToString(msg.Body))
+	}
+	if _, ok := msg.MessageAttributes[AttributeExtendedPayloadSize]; ok {
+		t.Fatal("payload size attribute kept after resolving")
+	}
+
+	handle, pointer := splitReceiptHandle(msg.ReceiptHandle)
+	if aws.ToString(handle) != "handle-original" || pointer == nil {
+		t.Fatalf("handle = %q, pointer = %v", aws.ToString(handle), pointer)
+	}
+	if err := q.deletePayload(context.Background(), pointer); err != nil {
+		t.Fatalf("deletePayload: %v", err)
+	}
+	if fake.len() != 0 {
+		t.Fatal("object not deleted from s3")
+	}
+
+	// Mensagens sem o atributo de tamanho nao sao tocadas.
+	plain := types.Message{Body: aws.String(string(body)), ReceiptHandle: aws.String("h")}
+	if err := q.resolvePayload(context.Background(), &plain); err != nil || aws.ToString(plain.ReceiptHandle) != "h" {
+		t.Fatalf("plain message changed: %v, %q", err, aws.ToString(plain.ReceiptHandle))
+	}
+}
+
+func TestOffloadFIFODeduplicatesByOriginalBody(t *testing.T) {
+	_, s3 := newFakeS3(t)
+	fake := newFakeSQS(t, func(operation string, input map[string]interface{}) interface{} {
+		return map[string]interface{}{"MessageId": "1"}
+	})
+	q := fake.queue("pedidos.fifo")
+	q.LargePayload = &LargePayloadConfig{Bucket: s3, AlwaysOffload: true}
+
+	for i := 0; i < 2; i++ {
+		if _, err := q.SendMessage([]byte("payload"), "grupo"); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+	}
+
+	hash := sha256.Sum256([]byte("payload"))
+	sends := fake.calls("SendMessage")
+	if sends[0]["MessageBody"] == sends[1]["MessageBody"] {
+		t.Fatal("expected distinct S3 pointers per send")
+	}
+	for i, send := range sends {
+		if send["MessageDeduplicationId"] != hex.EncodeToString(hash[:]) {
+			t.Fatalf("send %d deduplication id = %v, want hash of the original body", i, send["MessageDeduplicationId"])
+		}
+	}
+
+	// O id tambem vem do corpo original quando offload recebe params sem id.
+	params := sendParams{}
+	if _, err := q.offload(context.Background(), []byte("payload"), &params); err != nil {
+		t.Fatalf("offload: %v", err)
+	}
+	if aws.ToString(params.deduplicationId) != hex.EncodeToString(hash[:]) {
+		t.Fatalf("deduplication id = %q, want hash of the original body", aws.ToString(params.deduplicationId))
+	}
+}
@@ -0,0 +1,317 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidReceiptHandle = errors.New("queue: receipt handle is invalid or expired")
+	ErrMessageNotInflight   = errors.New("queue: message is not in flight")
+)
+
+type MemoryQueueConfig struct {
+	Name string
+	// FIFO exige MessageGroupId, entrega cada grupo em ordem e so libera a
+	// proxima mensagem do grupo quando a anterior e deletada ou volta a fila.
+	FIFO                bool
+	DeduplicationWindow time.Duration // padrao 5 minutos, como no SQS
+}
+
+// MemoryQueue e uma fila em memoria com a semantica do SQS: visibility
+// timeout, redelivery, contagem de recebimentos, deduplicacao e grupos FIFO.
+// Indicada para testes unitarios sem LocalStack.
+type MemoryQueue struct {
+	config MemoryQueueConfig
+
+	mu       sync.Mutex
+	messages []*storedMessage
+	dedup    map[string]dedupEntry
+	sequence int64
+	wake     chan struct{}
+
+	// Ganchos usados pela FileQueue para persistir o estado.
+	persist func(m *storedMessage) error
+	remove  func(m *storedMessage) error
+}
+
+type storedMessage struct {
+	Id              string                                 `json:"id"`
+	Sequence        int64                                  `json:"sequence"`
+	Body            string                                 `json:"body"`
+	Attributes      map[string]types.MessageAttributeValue `json:"attributes,omitempty"`
+	GroupId         string                                 `json:"group_id,omitempty"`
+	DeduplicationId string                                 `json:"deduplication_id,omitempty"`
+	SentAt          time.Time                              `json:"sent_at"`
+	VisibleAt       time.Time                              `json:"visible_at"`
+	ReceiveCount    int                                    `json:"receive_count"`
+	FirstReceivedAt time.Time                              `json:"first_received_at,omitempty"`
+	ReceiptHandle   string                                 `json:"receipt_handle,omitempty"`
+}
+
+type dedupEntry struct {
+	messageId string
+	sentAt    time.Time
+}
+
+func NewMemoryQueue(config MemoryQueueConfig) *MemoryQueue {
+	if config.DeduplicationWindow <= 0 {
+		config.DeduplicationWindow = 5 * time.Minute
+	}
+	return &MemoryQueue{
+		config: config,
+		dedup:  map[string]dedupEntry{},
+		wake:   make(chan struct{}),
+	}
+}
+
+func (q *MemoryQueue) SendMessage(message []byte, messageGroupId string, opts ...SendOption) (*sqs.SendMessageOutput, error) {
+	params := newSendParams(message, messageGroupId, q.config.FIFO, opts)
+
+	if size := messageSize(message, params.attributes); size > maxBatchBytes {
+		return nil, fmt.Errorf("queue: message of %d bytes exceeds the %d bytes limit", size, maxBatchBytes)
+	}
+	if q.config.FIFO {
+		if messageGroupId == "" {
+			return nil, fmt.Errorf("queue: MessageGroupId is required for FIFO queues")
+		}
+		if params.delaySeconds > 0 {
+			return nil, fmt.Errorf("queue: FIFO queues do not support per-message DelaySeconds")
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	dedupId := aws.ToString(params.deduplicationId)
+	if q.config.FIFO {
+		q.pruneDedup(now)
+		if entry, ok := q.dedup[dedupId]; ok {
+			return &sqs.SendMessageOutput{MessageId: aws.String(entry.messageId)}, nil
+		}
+	}
+
+	q.sequence++
+	m := &storedMessage{
+		Id:              uuid.New().String(),
+		Sequence:        q.sequence,
+		Body:            string(message),
+		Attributes:      params.attributes,
+		GroupId:         messageGroupId,
+		DeduplicationId: dedupId,
+		SentAt:          now,
+		VisibleAt:       now.Add(time.Duration(params.delaySeconds) * time.Second),
+	}
+	if q.persist != nil {
+		if err := q.persist(m); err != nil {
+			return nil, err
+		}
+	}
+
+	q.messages = append(q.messages, m)
+	if q.config.FIFO {
+		q.dedup[dedupId] = dedupEntry{messageId: m.Id, sentAt: now}
+	}
+	q.notify()
+
+	output := &sqs.SendMessageOutput{MessageId: aws.String(m.Id)}
+	if q.config.FIFO {
+		output.SequenceNumber = aws.String(strconv.FormatInt(m.Sequence, 10))
+	}
+	return output, nil
+}
+
+func (q *MemoryQueue) Consume(ctx context.Context, cfg ConsumerConfig) (<-chan types.Message, error) {
+	cfg.validate()
+
+	msgCh := make(chan types.Message, cfg.BufferSize)
+
+	go func() {
+		defer close(msgCh)
+
+		for {
+			messages, wake, next := q.receive(cfg.MaxNumberOfMessages, cfg.VisibilityTimeout)
+			if len(messages) == 0 {
+				wait := time.Duration(cfg.WaitTimeSeconds) * time.Second
+				if !next.IsZero() && time.Until(next) < wait {
+					wait = time.Until(next)
+				}
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-wake:
+				case <-timer.C:
+				}
+				timer.Stop()
+				continue
+			}
+
+			for _, m := range messages {
+				select {
+				case msgCh <- m:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return msgCh, nil
+}
+
+func (q *MemoryQueue) DeleteMessage(ctx context.Context, receiptHandle *string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, m := range q.messages {
+		if m.ReceiptHandle == "" || m.ReceiptHandle != aws.ToString(receiptHandle) {
+			continue
+		}
+		if q.remove != nil {
+			if err := q.remove(m); err != nil {
+				return err
+			}
+		}
+		q.messages = append(q.messages[:i], q.messages[i+1:]...)
+		q.notify()
+		return nil
+	}
+	return ErrInvalidReceiptHandle
+}
+
+func (q *MemoryQueue) ChangeMessageVisibility(ctx context.Context, receiptHandle *string, visibilityTimeout int32) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	for _, m := range q.messages {
+		if m.ReceiptHandle == "" || m.ReceiptHandle != aws.ToString(receiptHandle) {
+			continue
+		}
+		if !m.VisibleAt.After(now) {
+			return ErrMessageNotInflight
+		}
+		m.VisibleAt = now.Add(time.Duration(visibilityTimeout) * time.Second)
+		if q.persist != nil {
+			if err := q.persist(m); err != nil {
+				return err
+			}
+		}
+		q.notify()
+		return nil
+	}
+	return ErrInvalidReceiptHandle
+}
+
+// Len devolve quantas mensagens ainda nao foram deletadas, visiveis ou nao.
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+func (q *MemoryQueue) queueName() string {
+	return q.config.Name
+}
+
+// receive marca ate max mensagens visiveis como em processamento. Tambem
+// devolve o canal que sinaliza mudancas na fila e o proximo instante em que
+// uma mensagem fica visivel, para o consumer saber quanto esperar.
+func (q *MemoryQueue) receive(max, visibilityTimeout int32) ([]types.Message, <-chan struct{}, time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+
+	// Em FIFO, um grupo com mensagem em processamento fica bloqueado.
+	blocked := map[string]bool{}
+	if q.config.FIFO {
+		for _, m := range q.messages {
+			if m.ReceiptHandle != "" && m.VisibleAt.After(now) {
+				blocked[m.GroupId] = true
+			}
+		}
+	}
+
+	var received []types.Message
+	var next time.Time
+	for _, m := range q.messages {
+		if m.VisibleAt.After(now) {
+			if next.IsZero() || m.VisibleAt.Before(next) {
+				next = m.VisibleAt
+			}
+			continue
+		}
+		if int32(len(received)) >= max || blocked[m.GroupId] {
+			continue
+		}
+
+		m.ReceiveCount++
+		if m.FirstReceivedAt.IsZero() {
+			m.FirstReceivedAt = now
+		}
+		m.ReceiptHandle = uuid.New().String()
+		m.VisibleAt = now.Add(time.Duration(visibilityTimeout) * time.Second)
+		if q.persist != nil {
+			if err := q.persist(m); err != nil {
+				fmt.Println("Erro ao persistir mensagem:", err)
+			}
+		}
+		received = append(received, q.toMessage(m))
+	}
+	return received, q.wake, next
+}
+
+func (q *MemoryQueue) toMessage(m *storedMessage) types.Message {
+	attributes := map[string]string{
+		string(types.MessageSystemAttributeNameApproximateReceiveCount):          strconv.Itoa(m.ReceiveCount),
+		string(types.MessageSystemAttributeNameSentTimestamp):                    strconv.FormatInt(m.SentAt.UnixMilli(), 10),
+		string(types.MessageSystemAttributeNameApproximateFirstReceiveTimestamp): strconv.FormatInt(m.FirstReceivedAt.UnixMilli(), 10),
+	}
+	if m.GroupId != "" {
+		attributes[string(types.MessageSystemAttributeNameMessageGroupId)] = m.GroupId
+	}
+	if q.config.FIFO {
+		attributes[string(types.MessageSystemAttributeNameMessageDeduplicationId)] = m.DeduplicationId
+		attributes[string(types.MessageSystemAttributeNameSequenceNumber)] = strconv.FormatInt(m.Sequence, 10)
+	}
+
+	messageAttributes := map[string]types.MessageAttributeValue{}
+	for name, value := range m.Attributes {
+		messageAttributes[name] = value
+	}
+
+	return types.Message{
+		MessageId:         aws.String(m.Id),
+		ReceiptHandle:     aws.String(m.ReceiptHandle),
+		Body:              aws.String(m.Body),
+		Attributes:        attributes,
+		MessageAttributes: messageAttributes,
+	}
+}
+
+// notify acorda os consumers que aguardam mudancas na fila. Deve ser chamado
+// com q.mu travado.
+func (q *MemoryQueue) notify() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+func (q *MemoryQueue) pruneDedup(now time.Time) {
+	for id, entry := range q.dedup {
+		if now.Sub(entry.sentAt) > q.config.DeduplicationWindow {
+			delete(q.dedup, id)
+		}
+	}
+}
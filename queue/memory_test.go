@@ -0,0 +1,315 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// expire simula o fim do visibility timeout das mensagens em processamento,
+// sem esperar o relogio.
+func expire(q *MemoryQueue) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, m := range q.messages {
+		m.VisibleAt = time.Now().Add(-time.Millisecond)
+	}
+}
+
+func bodies(messages []types.Message) []string {
+	var out []string
+	for _, m := range messages {
+		out = append(out, aws.ToString(m.Body))
+	}
+	return out
+}
+
+func TestMemoryQueueRedeliversAfterVisibilityTimeout(t *testing.T) {
+	q := NewMemoryQueue(MemoryQueueConfig{Name: "test"})
+	if _, err := q.SendMessage([]byte("hello"), ""); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	msgCh, err := q.Consume(ctx, ConsumerConfig{VisibilityTimeout: 1, WaitTimeSeconds: 1})
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+
+	first := <-msgCh
+	start := time.Now()
+	second := <-msgCh
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Fatalf("message redelivered after %v, before the visibility timeout", elapsed)
+	}
+	if aws.ToString(first.MessageId) != aws.ToString(second.MessageId) {
+		t.Fatalf("redelivered %q, want %q", aws.ToString(second.MessageId), aws.ToString(first.MessageId))
+	}
+	if aws.ToString(first.ReceiptHandle) == aws.ToString(second.ReceiptHandle) {
+		t.Fatal("redelivery reused the receipt handle")
+	}
+	if got := ReceiveCount(second); got != 2 {
+		t.Fatalf("ReceiveCount = %d, want 2", got)
+	}
+}
+
+func TestMemoryQueueHidesInflightMessages(t *testing.T) {
+	q := NewMemoryQueue(MemoryQueueConfig{Name: "test"})
+	q.SendMessage([]byte("hello"), "")
+
+	received, _, _ := q.receive(10, 30)
+	if len(received) != 1 {
+		t.Fatalf("received %d messages, want 1", len(received))
+	}
+	if again, _, next := q.receive(10, 30); len(again) != 0 || next.IsZero() {
+		t.Fatalf("in-flight message received again: %v", bodies(again))
+	}
+	if q.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", q.Len())
+	}
+}
+
+func TestMemoryQueueReceiveCount(t *testing.T) {
+	q := NewMemoryQueue(MemoryQueueConfig{Name: "test"})
+	q.SendMessage([]byte("hello"), "")
+
+	for want := 1; want <= 3; want++ {
+		received, _, _ := q.receive(1, 30)
+		if len(received) != 1 {
+			t.Fatalf("receive %d: got %d messages", want, len(received))
+		}
+		if got := ReceiveCount(received[0]); got != want {
+			t.Fatalf("ReceiveCount = %d, want %d", got, want)
+		}
+		expire(q)
+	}
+}
+
+func TestMemoryQueueChangeMessageVisibility(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(MemoryQueueConfig{Name: "test"})
+	q.SendMessage([]byte("hello"), "")
+
+	received, _, _ := q.receive(1, 30)
+	if err := q.ChangeMessageVisibility(ctx, received[0].ReceiptHandle, 0); err != nil {
+		t.Fatalf("ChangeMessageVisibility: %v", err)
+	}
+	again, _, _ := q.receive(1, 30)
+	if len(again) != 1 {
+		t.Fatal("message not visible after ChangeMessageVisibility(0)")
+	}
+
+	// Mensagem visivel nao esta em processamento.
+	expire(q)
+	if err := q.ChangeMessageVisibility(ctx, again[0].ReceiptHandle, 10); !errors.Is(err, ErrMessageNotInflight) {
+		t.Fatalf("err = %v, want ErrMessageNotInflight", err)
+	}
+}
+
+func TestMemoryQueueRejectsStaleReceiptHandle(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(MemoryQueueConfig{Name: "test"})
+	q.SendMessage([]byte("hello"), "")
+
+	first, _, _ := q.receive(1, 30)
+	expire(q)
+	second, _, _ := q.receive(1, 30)
+
+	stale := first[0].ReceiptHandle
+	if err := q.DeleteMessage(ctx, stale); !errors.Is(err, ErrInvalidReceiptHandle) {
+		t.Fatalf("DeleteMessage with stale handle: err = %v, want ErrInvalidReceiptHandle", err)
+	}
+	if err := q.ChangeMessageVisibility(ctx, stale, 0); !errors.Is(err, ErrInvalidReceiptHandle) {
+		t.Fatalf("ChangeMessageVisibility with stale handle: err = %v, want ErrInvalidReceiptHandle", err)
+	}
+	if err := q.DeleteMessage(ctx, second[0].ReceiptHandle); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("Len = %d, want 0", q.Len())
+	}
+	if err := q.DeleteMessage(ctx, second[0].ReceiptHandle); !errors.Is(err, ErrInvalidReceiptHandle) {
+		t.Fatalf("second DeleteMessage: err = %v, want ErrInvalidReceiptHandle", err)
+	}
+}
+
+func TestMemoryQueueFIFOBlocksGroupUntilDeleted(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(MemoryQueueConfig{Name: "test.fifo", FIFO: true})
+	for _, m := range []struct{ body, group string }{
+		{"a1", "a"}, {"b1", "b"}, {"a2", "a"}, {"b2", "b"}, {"a3", "a"},
+	} {
+		if _, err := q.SendMessage([]byte(m.body), m.group); err != nil {
+			t.Fatalf("SendMessage(%s): %v", m.body, err)
+		}
+	}
+	if _, err := q.SendMessage([]byte("x"), ""); err == nil {
+		t.Fatal("SendMessage without MessageGroupId succeeded on a FIFO queue")
+	}
+
+	// Cada grupo entrega sua primeira mensagem e fica bloqueado enquanto ela
+	// esta em processamento.
+	var received []types.Message
+	for i := 0; i < 2; i++ {
+		batch, _, _ := q.receive(1, 30)
+		received = append(received, batch...)
+	}
+	if got := bodies(received); len(got) != 2 || got[0] != "a1" || got[1] != "b1" {
+		t.Fatalf("received %v, want [a1 b1]", got)
+	}
+	if again, _, _ := q.receive(10, 30); len(again) != 0 {
+		t.Fatalf("blocked groups delivered %v", bodies(again))
+	}
+
+	// Liberar o grupo a nao afeta o grupo b, que segue bloqueado.
+	if err := q.DeleteMessage(ctx, received[0].ReceiptHandle); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	next, _, _ := q.receive(1, 30)
+	if got := bodies(next); len(got) != 1 || got[0] != "a2" {
+		t.Fatalf("received %v, want [a2]", got)
+	}
+
+	// Uma mensagem que volta a fila e entregue de novo antes das seguintes.
+	if err := q.ChangeMessageVisibility(ctx, received[1].ReceiptHandle, 0); err != nil {
+		t.Fatalf("ChangeMessageVisibility: %v", err)
+	}
+	retry, _, _ := q.receive(1, 30)
+	if got := bodies(retry); len(got) != 1 || got[0] != "b1" {
+		t.Fatalf("received %v, want [b1]", got)
+	}
+	if seq := retry[0].Attributes[string(types.MessageSystemAttributeNameSequenceNumber)]; seq != "2" {
+		t.Fatalf("SequenceNumber = %q, want 2", seq)
+	}
+}
+
+func TestMemoryQueueDeduplicationWindow(t *testing.T) {
+	q := NewMemoryQueue(MemoryQueueConfig{Name: "test.fifo", FIFO: true, DeduplicationWindow: 50 * time.Millisecond})
+
+	first, err := q.SendMessage([]byte("hello"), "g", WithDeduplicationId("d1"))
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	duplicate, err := q.SendMessage([]byte("other body"), "g", WithDeduplicationId("d1"))
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if aws.ToString(duplicate.MessageId) != aws.ToString(first.MessageId) {
+		t.Fatalf("duplicate MessageId = %q, want %q", aws.ToString(duplicate.MessageId), aws.ToString(first.MessageId))
+	}
+	if q.Len() != 1 {
+		t.Fatalf("Len = %d, want 1 inside the deduplication window", q.Len())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	after, err := q.SendMessage([]byte("hello"), "g", WithDeduplicationId("d1"))
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if aws.ToString(after.MessageId) == aws.ToString(first.MessageId) {
+		t.Fatal("message deduplicated after the window expired")
+	}
+	if q.Len() != 2 {
+		t.Fatalf("Len = %d, want 2 after the deduplication window", q.Len())
+	}
+}
+
+func TestFileQueueReloadsAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	config := MemoryQueueConfig{Name: "test.fifo", FIFO: true}
+
+	q, err := NewFileQueue(dir, config)
+	if err != nil {
+		t.Fatalf("NewFileQueue: %v", err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if _, err := q.SendMessage([]byte("m"+id), "g", WithDeduplicationId(id), WithMessageAttribute("n", id)); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+	}
+	received, _, _ := q.receive(1, 30)
+	if err := q.DeleteMessage(ctx, received[0].ReceiptHandle); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	q.receive(1, 30)
+
+	reloaded, err := NewFileQueue(dir, config)
+	if err != nil {
+		t.Fatalf("NewFileQueue after restart: %v", err)
+	}
+	if reloaded.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", reloaded.Len())
+	}
+
+	// A mensagem em processamento continua invisivel e mantem a contagem.
+	if got, _, _ := reloaded.receive(10, 30); len(got) != 0 {
+		t.Fatalf("in-flight message delivered after restart: %v", bodies(got))
+	}
+	expire(reloaded.MemoryQueue)
+	got, _, _ := reloaded.receive(1, 30)
+	if len(got) != 1 || aws.ToString(got[0].Body) != "m2" {
+		t.Fatalf("received %v, want [m2]", bodies(got))
+	}
+	if count := ReceiveCount(got[0]); count != 2 {
+		t.Fatalf("ReceiveCount = %d, want 2", count)
+	}
+	if n := aws.ToString(got[0].MessageAttributes["n"].StringValue); n != "2" {
+		t.Fatalf("attribute n = %q, want 2", n)
+	}
+
+	// A deduplicacao e a sequencia sobrevivem ao reinicio.
+	if _, err := reloaded.SendMessage([]byte("m3"), "g", WithDeduplicationId("3")); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if reloaded.Len() != 2 {
+		t.Fatalf("Len = %d, want 2 after a duplicate send", reloaded.Len())
+	}
+	out, err := reloaded.SendMessage([]byte("m4"), "g", WithDeduplicationId("4"))
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if seq := aws.ToString(out.SequenceNumber); seq != "4" {
+		t.Fatalf("SequenceNumber = %q, want 4", seq)
+	}
+}
+
+func TestFileQueueRemovesStaleTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	config := MemoryQueueConfig{Name: "test"}
+
+	q, err := NewFileQueue(dir, config)
+	if err != nil {
+		t.Fatalf("NewFileQueue: %v", err)
+	}
+	if _, err := q.SendMessage([]byte("hello"), ""); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	// Simula uma escrita interrompida antes do rename.
+	stale := filepath.Join(dir, "00000000000000000002-interrompida.json.tmp")
+	if err := os.WriteFile(stale, []byte(`{"Id":`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	reloaded, err := NewFileQueue(dir, config)
+	if err != nil {
+		t.Fatalf("NewFileQueue after restart: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("stale temp file not removed: %v", err)
+	}
+	if reloaded.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", reloaded.Len())
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmps) != 0 {
+		t.Fatalf("temp files left after write: %v", tmps)
+	}
+}
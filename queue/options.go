@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/google/uuid"
+)
+
+type sendOptions struct {
+	deduplicationId string
+	contentBased    bool
+	randomDedup     bool
+	delaySeconds    int32
+	attributes      map[string]types.MessageAttributeValue
+}
+
+type SendOption func(*sendOptions)
+
+// WithDeduplicationId define o id de deduplicacao da fila FIFO. Use o mesmo id
+// nas retentativas para que o SQS descarte o envio duplicado.
+func WithDeduplicationId(id string) SendOption {
+	return func(o *sendOptions) {
+		o.deduplicationId = id
+	}
+}
+
+// WithContentBasedDeduplication usa o SHA-256 do corpo como id de
+// deduplicacao. E o padrao das filas FIFO; a opcao deixa a escolha explicita.
+func WithContentBasedDeduplication() SendOption {
+	return func(o *sendOptions) {
+		o.contentBased = true
+	}
+}
+
+// WithRandomDeduplicationId gera um id aleatorio a cada envio, desativando a
+// deduplicacao da fila FIFO: retentativas do mesmo envio geram duplicatas.
+func WithRandomDeduplicationId() SendOption {
+	return func(o *sendOptions) {
+		o.randomDedup = true
+	}
+}
+
+// WithDelaySeconds atrasa a entrega da mensagem (0 a 900 segundos). Filas
+// FIFO aceitam apenas o atraso configurado na fila.
+func WithDelaySeconds(seconds int32) SendOption {
+	return func(o *sendOptions) {
+		o.delaySeconds = seconds
+	}
+}
+
+// WithMessageAttribute adiciona um atributo do tipo String a mensagem.
+func WithMessageAttribute(name, value string) SendOption {
+	return WithMessageAttributes(map[string]types.MessageAttributeValue{
+		name: {DataType: aws.String("String"), StringValue: aws.String(value)},
+	})
+}
+
+// WithMessageAttributes adiciona atributos com qualquer tipo suportado pelo
+// SQS (String, Number ou Binary).
+func WithMessageAttributes(attributes map[string]types.MessageAttributeValue) SendOption {
+	return func(o *sendOptions) {
+		if o.attributes == nil {
+			o.attributes = map[string]types.MessageAttributeValue{}
+		}
+		for name, value := range attributes {
+			o.attributes[name] = value
+		}
+	}
+}
+
+// sendParams resolve os campos comuns a SendMessage e SendMessageBatch.
+type sendParams struct {
+	messageGroupId  *string
+	deduplicationId *string
+	delaySeconds    int32
+	attributes      map[string]types.MessageAttributeValue
+}
+
+func newSendParams(body []byte, messageGroupId string, fifo bool, opts []SendOption) sendParams {
+	var options sendOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	params := sendParams{
+		delaySeconds: options.delaySeconds,
+		attributes:   options.attributes,
+	}
+	if messageGroupId != "" {
+		params.messageGroupId = aws.String(messageGroupId)
+	}
+
+	// Filas standard nao aceitam id de deduplicacao. Em filas FIFO, sem
+	// opcao, o id e o SHA-256 do corpo: retentativas do mesmo envio sao
+	// descartadas mesmo em filas sem ContentBasedDeduplication.
+	if fifo {
+		switch {
+		case options.deduplicationId != "":
+			params.deduplicationId = aws.String(options.deduplicationId)
+		case options.randomDedup && !options.contentBased:
+			params.deduplicationId = aws.String(uuid.New().String())
+		default:
+			hash := sha256.Sum256(body)
+			params.deduplicationId = aws.String(hex.EncodeToString(hash[:]))
+		}
+	}
+	return params
+}
+
+func (q *ToSqs) isFifo() bool {
+	return strings.HasSuffix(q.QueueUrl, ".fifo")
+}
+
+// messageSize estima o tamanho da mensagem como o SQS calcula para o limite
+// de 256 KB: corpo mais nome, tipo e valor de cada atributo.
+func messageSize(body []byte, attributes map[string]types.MessageAttributeValue) int {
+	size := len(body)
+	for name, value := range attributes {
+		size += len(name) + len(aws.ToString(value.DataType)) + len(aws.ToString(value.StringValue)) + len(value.BinaryValue)
+	}
+	return size
+}
@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestSendParamsDeduplication(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	hash := sha256.Sum256(body)
+
+	tests := []struct {
+		name string
+		fifo bool
+		opts []SendOption
+		want string
+	}{
+		{"fifo without option", true, nil, hex.EncodeToString(hash[:])},
+		{"fifo explicit id", true, []SendOption{WithDeduplicationId("pedido-1")}, "pedido-1"},
+		{"fifo content based", true, []SendOption{WithContentBasedDeduplication()}, hex.EncodeToString(hash[:])},
+		{"explicit id wins", true, []SendOption{WithContentBasedDeduplication(), WithDeduplicationId("pedido-1")}, "pedido-1"},
+		{"content based wins over random", true, []SendOption{WithRandomDeduplicationId(), WithContentBasedDeduplication()}, hex.EncodeToString(hash[:])},
+		{"standard ignores id", false, []SendOption{WithDeduplicationId("pedido-1")}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := newSendParams(body, "grupo", tt.fifo, tt.opts)
+			if got := aws.ToString(params.deduplicationId); got != tt.want {
+				t.Fatalf("deduplicationId = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	first := newSendParams(body, "grupo", true, []SendOption{WithRandomDeduplicationId()})
+	second := newSendParams(body, "grupo", true, []SendOption{WithRandomDeduplicationId()})
+	if first.deduplicationId == nil || aws.ToString(first.deduplicationId) == aws.ToString(second.deduplicationId) {
+		t.Fatal("WithRandomDeduplicationId should generate a new id per send")
+	}
+}
+
+func TestMemoryQueueFIFODeduplicatesByContentWithoutOptions(t *testing.T) {
+	q := NewMemoryQueue(MemoryQueueConfig{FIFO: true})
+	first, err := q.SendMessage([]byte("a"), "grupo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := q.SendMessage([]byte("a"), "grupo")
+	if aws.ToString(first.MessageId) != aws.ToString(second.MessageId) || q.Len() != 1 {
+		t.Fatal("same body should be deduplicated without options")
+	}
+
+	if _, err := q.SendMessage([]byte("a"), "grupo", WithRandomDeduplicationId()); err != nil {
+		t.Fatal(err)
+	}
+	if q.Len() != 2 {
+		t.Fatalf("Len = %d, want WithRandomDeduplicationId to skip deduplication", q.Len())
+	}
+}
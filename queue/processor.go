@@ -0,0 +1,206 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// RetryImmediately devolve a mensagem com erro para a fila sem esperar o
+// VisibilityTimeout.
+const RetryImmediately time.Duration = -1
+
+// ErrConsumerStopped e devolvido por Run quando o canal do consumer fecha sem
+// que o contexto tenha sido cancelado, por exemplo apos queda da conexao.
+var ErrConsumerStopped = errors.New("queue: consumer stopped before the context was canceled")
+
+// visibilityEmulator e implementado pelos consumers de brokers sem visibility
+// timeout (RabbitMQ e Kafka): neles a mensagem sem confirmacao so volta para a
+// fila quando o Processor a libera com ChangeMessageVisibility.
+type visibilityEmulator interface {
+	withoutVisibilityTimeout()
+}
+
+type Handler func(ctx context.Context, msg types.Message) error
+
+type ProcessorConfig struct {
+	Workers  int // padrao 5 handlers concorrentes
+	Consumer ConsumerConfig
+	// RetryDelay define quando a mensagem com erro volta a ficar visivel.
+	// 0 mantem o VisibilityTimeout do consumer; RetryImmediately reenvia na hora.
+	// O SQS trabalha em segundos, entao fracoes sao arredondadas para cima.
+	RetryDelay time.Duration
+	// ShutdownTimeout limita a espera pelos handlers em andamento apos o
+	// cancelamento do contexto; 0 espera ate todos terminarem.
+	ShutdownTimeout time.Duration
+	// Heartbeat, quando definido, renova a visibilidade das mensagens enquanto
+	// o handler executa. Extension zero usa o VisibilityTimeout do consumer.
+	Heartbeat *HeartbeatConfig
+	// DeadLetter, quando definido, move para a DLQ as mensagens que falharem
+	// MaxAttempts vezes, em vez de aguardar a redrive policy do SQS.
+	DeadLetter *DeadLetterConfig
+}
+
+// Processor consome a fila com N handlers concorrentes, deleta as mensagens
+// processadas com sucesso e deixa as que falharam para nova entrega.
+type Processor struct {
+	queue   Consumer
+	config  ProcessorConfig
+	handler Handler
+}
+
+func NewProcessor(queue Consumer, config ProcessorConfig, handler Handler) *Processor {
+	if config.Workers <= 0 {
+		config.Workers = 5
+	}
+	if config.Consumer.BufferSize <= 0 {
+		config.Consumer.BufferSize = config.Workers
+	}
+	config.Consumer.validate()
+	if config.Heartbeat != nil {
+		heartbeat := *config.Heartbeat
+		if heartbeat.Extension <= 0 {
+			heartbeat.Extension = config.Consumer.VisibilityTimeout
+		}
+		config.Heartbeat = &heartbeat
+	}
+	if config.DeadLetter != nil {
+		deadLetter := *config.DeadLetter
+		if deadLetter.MaxAttempts <= 0 {
+			deadLetter.MaxAttempts = 5
+		}
+		config.DeadLetter = &deadLetter
+	}
+
+	return &Processor{
+		queue:   queue,
+		config:  config,
+		handler: handler,
+	}
+}
+
+// Run bloqueia ate o contexto ser cancelado. Depois disso para de receber
+// mensagens e aguarda os handlers em andamento antes de retornar. Se o
+// consumer parar antes do cancelamento, Run devolve ErrConsumerStopped.
+func (p *Processor) Run(ctx context.Context) error {
+	msgCh, err := p.queue.Consume(ctx, p.config.Consumer)
+	if err != nil {
+		return err
+	}
+
+	// Os handlers nao herdam o cancelamento de ctx para que terminem o
+	// trabalho em andamento; so sao cancelados apos o ShutdownTimeout.
+	handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+
+	if p.config.ShutdownTimeout > 0 {
+		stop := context.AfterFunc(ctx, func() {
+			timer := time.AfterFunc(p.config.ShutdownTimeout, cancelHandlers)
+			context.AfterFunc(handlerCtx, func() { timer.Stop() })
+		})
+		defer stop()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range msgCh {
+				if ctx.Err() != nil {
+					// Mensagens ja recebidas mas nao iniciadas voltam para a fila
+					// quando o VisibilityTimeout expirar, ou na hora nos brokers
+					// sem visibility timeout.
+					p.release(handlerCtx, msg)
+					continue
+				}
+				p.process(handlerCtx, msg)
+			}
+		}()
+	}
+
+	wg.Wait()
+	if ctx.Err() == nil {
+		return ErrConsumerStopped
+	}
+	fmt.Println("Processor finalizado...")
+	return nil
+}
+
+func (p *Processor) process(ctx context.Context, msg types.Message) {
+	stopHeartbeat := func() {}
+	if p.config.Heartbeat != nil {
+		stopHeartbeat = startHeartbeat(ctx, p.queue, msg.ReceiptHandle, *p.config.Heartbeat)
+	}
+
+	// Mensagens cujo corpo nao pode ser buscado contam como falha, para que
+	// cheguem a DLQ em vez de serem ignoradas para sempre.
+	err := PayloadError(msg)
+	if err == nil {
+		err = p.handle(ctx, msg)
+	}
+	stopHeartbeat()
+
+	if err == nil {
+		if err := p.queue.DeleteMessage(ctx, msg.ReceiptHandle); err != nil {
+			fmt.Println("Erro ao deletar mensagem:", err)
+		}
+		return
+	}
+
+	fmt.Printf("Erro ao processar mensagem %s: %v\n", messageId(msg), err)
+
+	if dl := p.config.DeadLetter; dl != nil && dl.Queue != nil && ReceiveCount(msg) >= dl.MaxAttempts {
+		if err := moveToDLQ(ctx, p.queue, msg, dl.Queue, err); err != nil {
+			fmt.Println("Erro ao mover mensagem para a DLQ:", err)
+		}
+		return
+	}
+
+	timeout := int32((p.config.RetryDelay + time.Second - 1) / time.Second)
+	switch {
+	case p.config.RetryDelay < 0:
+		timeout = 0
+	case p.config.RetryDelay == 0:
+		if _, ok := p.queue.(visibilityEmulator); !ok {
+			return
+		}
+		timeout = p.config.Consumer.VisibilityTimeout
+	}
+	if err := p.queue.ChangeMessageVisibility(ctx, msg.ReceiptHandle, timeout); err != nil {
+		fmt.Println("Erro ao alterar visibilidade da mensagem:", err)
+	}
+}
+
+// release devolve na hora a mensagem nao iniciada nos brokers sem visibility
+// timeout, que a manteriam reservada ate o consumer fechar.
+func (p *Processor) release(ctx context.Context, msg types.Message) {
+	if _, ok := p.queue.(visibilityEmulator); !ok {
+		return
+	}
+	if err := p.queue.ChangeMessageVisibility(ctx, msg.ReceiptHandle, 0); err != nil {
+		fmt.Println("Erro ao devolver mensagem para a fila:", err)
+	}
+}
+
+// handle executa o handler convertendo panic em erro, para que um worker nao
+// derrube o processo inteiro.
+func (p *Processor) handle(ctx context.Context, msg types.Message) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return p.handler(ctx, msg)
+}
+
+func messageId(msg types.Message) string {
+	if msg.MessageId == nil {
+		return ""
+	}
+	return *msg.MessageId
+}
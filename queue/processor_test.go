@@ -0,0 +1,332 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// closingConsumer entrega as mensagens informadas e fecha o canal, como um
+// consumer que perdeu a conexao com o broker.
+type closingConsumer struct {
+	messages []types.Message
+	deleted  atomic.Int32
+}
+
+func (c *closingConsumer) Consume(ctx context.Context, cfg ConsumerConfig) (<-chan types.Message, error) {
+	msgCh := make(chan types.Message, len(c.messages))
+	for _, msg := range c.messages {
+		msgCh <- msg
+	}
+	close(msgCh)
+	return msgCh, nil
+}
+
+func (c *closingConsumer) DeleteMessage(ctx context.Context, receiptHandle *string) error {
+	c.deleted.Add(1)
+	return nil
+}
+
+func (c *closingConsumer) ChangeMessageVisibility(ctx context.Context, receiptHandle *string, visibilityTimeout int32) error {
+	return nil
+}
+
+func TestProcessorRunReportsStoppedConsumer(t *testing.T) {
+	consumer := &closingConsumer{messages: []types.Message{{MessageId: aws.String("1"), ReceiptHandle: aws.String("h1")}}}
+	processor := NewProcessor(consumer, ProcessorConfig{Workers: 2}, func(ctx context.Context, msg types.Message) error {
+		return nil
+	})
+
+	if err := processor.Run(context.Background()); !errors.Is(err, ErrConsumerStopped) {
+		t.Fatalf("err = %v, want ErrConsumerStopped", err)
+	}
+	if consumer.deleted.Load() != 1 {
+		t.Fatalf("deleted = %d, want in-flight message processed before returning", consumer.deleted.Load())
+	}
+}
+
+func TestProcessorRunReturnsNilOnCancel(t *testing.T) {
+	q := NewMemoryQueue(MemoryQueueConfig{})
+	ctx, cancel := context.WithCancel(context.Background())
+	processed := make(chan struct{})
+
+	processor := NewProcessor(q, ProcessorConfig{Consumer: ConsumerConfig{WaitTimeSeconds: 1}}, func(ctx context.Context, msg types.Message) error {
+		close(processed)
+		return nil
+	})
+	done := make(chan error, 1)
+	go func() { done <- processor.Run(ctx) }()
+
+	q.SendMessage([]byte("a"), "")
+	select {
+	case <-processed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not processed")
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("err = %v, want nil after cancel", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	if q.Len() != 0 {
+		t.Fatalf("Len = %d, want the processed message deleted", q.Len())
+	}
+}
+
+func TestProcessorMovesUnavailablePayloadToDLQ(t *testing.T) {
+	msg := types.Message{
+		MessageId:     aws.String("1"),
+		ReceiptHandle: aws.String("h1"),
+		Body:          aws.String(`["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"b","s3Key":"k"}]`),
+		Attributes:    map[string]string{string(types.MessageSystemAttributeNameApproximateReceiveCount): "2"},
+	}
+	markPayloadError(&msg, errors.New("NoSuchKey"))
+	if err := PayloadError(msg); !errors.Is(err, ErrPayloadUnavailable) {
+		t.Fatalf("PayloadError = %v, want ErrPayloadUnavailable", err)
+	}
+
+	consumer := &closingConsumer{messages: []types.Message{msg}}
+	dlq := NewMemoryQueue(MemoryQueueConfig{})
+	var called atomic.Bool
+	processor := NewProcessor(consumer, ProcessorConfig{DeadLetter: &DeadLetterConfig{Queue: dlq, MaxAttempts: 2}}, func(ctx context.Context, msg types.Message) error {
+		called.Store(true)
+		return nil
+	})
+	processor.Run(context.Background())
+
+	if called.Load() {
+		t.Fatal("handler should not run for a message without payload")
+	}
+	if dlq.Len() != 1 || consumer.deleted.Load() != 1 {
+		t.Fatalf("dlq = %d, deleted = %d; want message moved to the DLQ", dlq.Len(), consumer.deleted.Load())
+	}
+}
+
+// recordingQueue registra as chamadas que o Processor faz na MemoryQueue.
+type recordingQueue struct {
+	*MemoryQueue
+
+	mu         sync.Mutex
+	visibility []int32
+}
+
+func (q *recordingQueue) ChangeMessageVisibility(ctx context.Context, receiptHandle *string, visibilityTimeout int32) error {
+	q.mu.Lock()
+	q.visibility = append(q.visibility, visibilityTimeout)
+	q.mu.Unlock()
+	return q.MemoryQueue.ChangeMessageVisibility(ctx, receiptHandle, visibilityTimeout)
+}
+
+func (q *recordingQueue) visibilityCalls() []int32 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]int32(nil), q.visibility...)
+}
+
+// runProcessor executa o Processor em background e devolve a funcao que o
+// cancela e espera Run retornar.
+func runProcessor(t *testing.T, processor *Processor) func() error {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- processor.Run(ctx) }()
+
+	return func() error {
+		cancel()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run did not return after cancel")
+			return nil
+		}
+	}
+}
+
+func TestProcessorDeletesOnSuccess(t *testing.T) {
+	q := NewMemoryQueue(MemoryQueueConfig{})
+	for i := 0; i < 3; i++ {
+		q.SendMessage([]byte("ok"), "")
+	}
+
+	var handled atomic.Int32
+	stop := runProcessor(t, NewProcessor(q, ProcessorConfig{}, func(ctx context.Context, msg types.Message) error {
+		handled.Add(1)
+		return nil
+	}))
+	waitFor(t, func() bool { return q.Len() == 0 })
+	if err := stop(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if handled.Load() != 3 {
+		t.Fatalf("handled = %d, want 3", handled.Load())
+	}
+}
+
+func TestProcessorKeepsFailedMessage(t *testing.T) {
+	q := &recordingQueue{MemoryQueue: NewMemoryQueue(MemoryQueueConfig{})}
+	q.SendMessage([]byte("fail"), "")
+
+	var handled atomic.Int32
+	stop := runProcessor(t, NewProcessor(q, ProcessorConfig{}, func(ctx context.Context, msg types.Message) error {
+		handled.Add(1)
+		return errors.New("boom")
+	}))
+	waitFor(t, func() bool { return handled.Load() == 1 })
+	stop()
+
+	// Com RetryDelay zero a mensagem espera o VisibilityTimeout do consumer.
+	if q.Len() != 1 {
+		t.Fatalf("Len = %d, want the failed message kept", q.Len())
+	}
+	if calls := q.visibilityCalls(); len(calls) != 0 {
+		t.Fatalf("ChangeMessageVisibility calls = %v, want none", calls)
+	}
+	if received, _, _ := q.receive(1, 30); len(received) != 0 {
+		t.Fatal("failed message visible before the VisibilityTimeout")
+	}
+}
+
+func TestProcessorRetryDelay(t *testing.T) {
+	tests := []struct {
+		name  string
+		delay time.Duration
+		want  int32
+	}{
+		{"immediately", RetryImmediately, 0},
+		{"sub-second rounds up", 500 * time.Millisecond, 1},
+		{"whole seconds", 2 * time.Second, 2},
+		{"fraction rounds up", 1500 * time.Millisecond, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &recordingQueue{MemoryQueue: NewMemoryQueue(MemoryQueueConfig{})}
+			q.SendMessage([]byte("fail"), "")
+
+			stop := runProcessor(t, NewProcessor(q, ProcessorConfig{Workers: 1, RetryDelay: tt.delay}, func(ctx context.Context, msg types.Message) error {
+				return errors.New("boom")
+			}))
+			waitFor(t, func() bool { return len(q.visibilityCalls()) > 0 })
+			stop()
+
+			if got := q.visibilityCalls()[0]; got != tt.want {
+				t.Fatalf("visibility timeout = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessorRecoversPanic(t *testing.T) {
+	q := NewMemoryQueue(MemoryQueueConfig{})
+	q.SendMessage([]byte("panic"), "")
+
+	var attempts atomic.Int32
+	stop := runProcessor(t, NewProcessor(q, ProcessorConfig{Workers: 1, RetryDelay: RetryImmediately}, func(ctx context.Context, msg types.Message) error {
+		if attempts.Add(1) == 1 {
+			panic("boom")
+		}
+		return nil
+	}))
+	waitFor(t, func() bool { return q.Len() == 0 })
+	if err := stop(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if attempts.Load() != 2 {
+		t.Fatalf("attempts = %d, want the panicking message retried once", attempts.Load())
+	}
+}
+
+func TestProcessorWorkerConcurrency(t *testing.T) {
+	const workers = 3
+	q := NewMemoryQueue(MemoryQueueConfig{})
+	for i := 0; i < 2*workers; i++ {
+		q.SendMessage([]byte("ok"), "")
+	}
+
+	var active, peak atomic.Int32
+	stop := runProcessor(t, NewProcessor(q, ProcessorConfig{Workers: workers}, func(ctx context.Context, msg types.Message) error {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			current := peak.Load()
+			if n <= current || peak.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		// Segura o handler ate todos os workers estarem ocupados.
+		deadline := time.Now().Add(time.Second)
+		for peak.Load() < workers && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	}))
+	waitFor(t, func() bool { return q.Len() == 0 })
+	stop()
+
+	if peak.Load() != workers {
+		t.Fatalf("peak concurrency = %d, want %d", peak.Load(), workers)
+	}
+}
+
+func TestProcessorShutdownTimeout(t *testing.T) {
+	q := NewMemoryQueue(MemoryQueueConfig{})
+	q.SendMessage([]byte("slow"), "")
+
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	stop := runProcessor(t, NewProcessor(q, ProcessorConfig{ShutdownTimeout: 100 * time.Millisecond}, func(ctx context.Context, msg types.Message) error {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	}))
+	<-started
+
+	start := time.Now()
+	if err := stop(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("handler canceled after %v, before the ShutdownTimeout", elapsed)
+	}
+	select {
+	case <-canceled:
+	default:
+		t.Fatal("handler context was not canceled")
+	}
+	if q.Len() != 1 {
+		t.Fatalf("Len = %d, want the interrupted message kept", q.Len())
+	}
+}
+
+func TestProcessorWaitsForHandlersWithoutShutdownTimeout(t *testing.T) {
+	q := NewMemoryQueue(MemoryQueueConfig{})
+	q.SendMessage([]byte("slow"), "")
+
+	started := make(chan struct{})
+	stop := runProcessor(t, NewProcessor(q, ProcessorConfig{}, func(ctx context.Context, msg types.Message) error {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		return ctx.Err()
+	}))
+	<-started
+
+	if err := stop(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("Len = %d, want the in-flight handler finished and deleted", q.Len())
+	}
+}
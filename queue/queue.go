@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Publisher e Consumer abstraem o broker usado pela aplicacao. ToSqs,
+// ToRabbit, ToKafka, MemoryQueue e FileQueue implementam ambas, permitindo
+// trocar o SQS por uma fila em memoria nos testes ou por uma fila em disco no
+// desenvolvimento.
+type Publisher interface {
+	SendMessage(message []byte, messageGroupId string, opts ...SendOption) (*sqs.SendMessageOutput, error)
+}
+
+type Consumer interface {
+	Consume(ctx context.Context, cfg ConsumerConfig) (<-chan types.Message, error)
+	DeleteMessage(ctx context.Context, receiptHandle *string) error
+	ChangeMessageVisibility(ctx context.Context, receiptHandle *string, visibilityTimeout int32) error
+}
+
+type Queue interface {
+	Publisher
+	Consumer
+}
+
+var (
+	_ Queue = (*ToSqs)(nil)
+	_ Queue = (*MemoryQueue)(nil)
+	_ Queue = (*FileQueue)(nil)
+	_ Queue = (*ToRabbit)(nil)
+	_ Queue = (*ToKafka)(nil)
+)
+
+// IsReservedAttribute informa se o atributo e adicionado pela propria
+// biblioteca, como os dlq_* e o tamanho dos payloads gravados no S3, e nao
+// pelo chamador.
+func IsReservedAttribute(name string) bool {
+	return strings.HasPrefix(name, "dlq_") || name == AttributeExtendedPayloadSize || name == legacyPayloadSizeAttribute
+}
+
+// queueName identifica a fila nos atributos de DLQ.
+func queueName(c Consumer) string {
+	if named, ok := c.(interface{ queueName() string }); ok {
+		return named.queueName()
+	}
+	return ""
+}
+
+func (q *ToSqs) queueName() string {
+	return q.QueueUrl
+}
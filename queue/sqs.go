@@ -3,14 +3,13 @@ package queue
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
-	"github.com/google/uuid"
+	"github.com/simpplify-org/GO-data-connector-lib/awsconfig"
 )
 
 type ToSqs struct {
@@ -18,6 +17,14 @@ type ToSqs struct {
 	AwsSecretKey string
 	AwsRegion    string
 	QueueUrl     string
+	// Endpoint substitui o endpoint do SQS, ex.: http://localhost:4566 para o
+	// LocalStack. Deve ser definido antes da primeira chamada.
+	Endpoint string
+	// LargePayload habilita o envio de corpos grandes pelo S3 (opcional).
+	LargePayload *LargePayloadConfig
+
+	mu     sync.Mutex
+	client *sqs.Client
 }
 type ConsumerConfig struct {
 	MaxNumberOfMessages int32         // padrao 10 segundos
@@ -27,6 +34,24 @@ type ConsumerConfig struct {
 	BufferSize          int           // padrao 20 mensagens
 }
 
+func (c *ConsumerConfig) validate() {
+	if c.MaxNumberOfMessages <= 0 {
+		c.MaxNumberOfMessages = 10
+	}
+	if c.WaitTimeSeconds <= 0 {
+		c.WaitTimeSeconds = 10
+	}
+	if c.VisibilityTimeout <= 0 {
+		c.VisibilityTimeout = 30
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = 5 * time.Second
+	}
+	if c.BufferSize <= 0 {
+		c.BufferSize = 20
+	}
+}
+
 func NewToSqs(AwsAccessKey, AwsSecretKey, AwsRegion, QueueUrl string) *ToSqs {
 	return &ToSqs{
 		AwsAccessKey: AwsAccessKey,
@@ -36,28 +61,59 @@ func NewToSqs(AwsAccessKey, AwsSecretKey, AwsRegion, QueueUrl string) *ToSqs {
 	}
 }
 
+// NewToSqsFromConfig usa um aws.Config ja montado, normalmente por
+// awsconfig.Load, compartilhando credenciais com outros clients.
+func NewToSqsFromConfig(cfg aws.Config, QueueUrl string) *ToSqs {
+	return &ToSqs{
+		AwsRegion: cfg.Region,
+		QueueUrl:  QueueUrl,
+		client:    sqs.NewFromConfig(cfg),
+	}
+}
+
+// getClient cria o client na primeira chamada e o reutiliza nas seguintes.
+// Sem AwsAccessKey, usa a cadeia padrao de credenciais da AWS.
 func (q *ToSqs) getClient() (*sqs.Client, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(q.AwsRegion),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(q.AwsAccessKey, q.AwsSecretKey, "")),
-	)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.client != nil {
+		return q.client, nil
+	}
+
+	cfg, err := awsconfig.Load(context.TODO(), awsconfig.Config{
+		Region:    q.AwsRegion,
+		AccessKey: q.AwsAccessKey,
+		SecretKey: q.AwsSecretKey,
+		Endpoint:  q.Endpoint,
+	})
 	if err != nil {
 		return nil, err
 	}
-	return sqs.NewFromConfig(cfg), nil
+	q.client = sqs.NewFromConfig(cfg)
+	return q.client, nil
 }
 
-func (q *ToSqs) SendMessage(message []byte, messageGroupId string) (*sqs.SendMessageOutput, error) {
+// SendMessage envia a mensagem para a fila. messageGroupId pode ser vazio em
+// filas standard. Em filas FIFO, sem opcao de deduplicacao, o id e o SHA-256
+// do corpo.
+func (q *ToSqs) SendMessage(message []byte, messageGroupId string, opts ...SendOption) (*sqs.SendMessageOutput, error) {
 	client, err := q.getClient()
 	if err != nil {
 		return nil, err
 	}
-	strUUid := uuid.New()
+	params := newSendParams(message, messageGroupId, q.isFifo(), opts)
+	body, err := q.offload(context.TODO(), message, &params)
+	if err != nil {
+		return &sqs.SendMessageOutput{}, err
+	}
 	input := &sqs.SendMessageInput{
-		MessageBody:            aws.String(string(message)),
+		MessageBody:            aws.String(string(body)),
 		QueueUrl:               aws.String(q.QueueUrl),
-		MessageGroupId:         aws.String(messageGroupId),
-		MessageDeduplicationId: aws.String(strUUid.String()),
+		MessageGroupId:         params.messageGroupId,
+		MessageDeduplicationId: params.deduplicationId,
+		DelaySeconds:           params.delaySeconds,
+		MessageAttributes:      params.attributes,
 	}
 
 	result, err := client.SendMessage(context.TODO(), input)
@@ -74,21 +130,7 @@ func (q *ToSqs) Consume(ctx context.Context, cfg ConsumerConfig) (<-chan types.M
 		return nil, err
 	}
 
-	if cfg.MaxNumberOfMessages <= 0 {
-		cfg.MaxNumberOfMessages = 10
-	}
-	if cfg.WaitTimeSeconds <= 0 {
-		cfg.WaitTimeSeconds = 10
-	}
-	if cfg.VisibilityTimeout <= 0 {
-		cfg.VisibilityTimeout = 30
-	}
-	if cfg.PollInterval <= 0 {
-		cfg.PollInterval = 5 * time.Second
-	}
-	if cfg.BufferSize <= 0 {
-		cfg.BufferSize = 20
-	}
+	cfg.validate()
 
 	msgCh := make(chan types.Message, cfg.BufferSize)
 
@@ -102,10 +144,12 @@ func (q *ToSqs) Consume(ctx context.Context, cfg ConsumerConfig) (<-chan types.M
 				return
 			default:
 				resp, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
-					QueueUrl:            aws.String(q.QueueUrl),
-					MaxNumberOfMessages: cfg.MaxNumberOfMessages,
-					WaitTimeSeconds:     cfg.WaitTimeSeconds,
-					VisibilityTimeout:   cfg.VisibilityTimeout,
+					QueueUrl:                    aws.String(q.QueueUrl),
+					MaxNumberOfMessages:         cfg.MaxNumberOfMessages,
+					WaitTimeSeconds:             cfg.WaitTimeSeconds,
+					VisibilityTimeout:           cfg.VisibilityTimeout,
+					MessageAttributeNames:       []string{"All"},
+					MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
 				})
 				if err != nil {
 					fmt.Println("Erro ao receber mensagem:", err)
@@ -118,6 +162,10 @@ func (q *ToSqs) Consume(ctx context.Context, cfg ConsumerConfig) (<-chan types.M
 				}
 
 				for _, m := range resp.Messages {
+					if err := q.resolvePayload(ctx, &m); err != nil {
+						fmt.Println("Erro ao buscar payload da mensagem:", err)
+						markPayloadError(&m, err)
+					}
 					select {
 					case msgCh <- m:
 					case <-ctx.Done():
@@ -136,9 +184,28 @@ func (q *ToSqs) DeleteMessage(ctx context.Context, receiptHandle *string) error
 		return err
 	}
 
+	receiptHandle, pointer := splitReceiptHandle(receiptHandle)
 	_, err = client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
 		QueueUrl:      aws.String(q.QueueUrl),
 		ReceiptHandle: receiptHandle,
 	})
+	if err != nil {
+		return err
+	}
+	return q.deletePayload(ctx, pointer)
+}
+
+func (q *ToSqs) ChangeMessageVisibility(ctx context.Context, receiptHandle *string, visibilityTimeout int32) error {
+	client, err := q.getClient()
+	if err != nil {
+		return err
+	}
+
+	receiptHandle, _ = splitReceiptHandle(receiptHandle)
+	_, err = client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(q.QueueUrl),
+		ReceiptHandle:     receiptHandle,
+		VisibilityTimeout: visibilityTimeout,
+	})
 	return err
 }
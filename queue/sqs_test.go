@@ -0,0 +1,148 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSQS responde a API JSON do SQS. O handler recebe a operacao (ex.:
+// "SendMessage") e o corpo da requisicao, e devolve o corpo da resposta.
+type fakeSQS struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []sqsRequest
+}
+
+type sqsRequest struct {
+	operation string
+	input     map[string]interface{}
+}
+
+func newFakeSQS(t *testing.T, handler func(operation string, input map[string]interface{}) interface{}) *fakeSQS {
+	t.Helper()
+	fake := &fakeSQS{}
+	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AmazonSQS.")
+		var input map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Errorf("%s: invalid request body: %v", operation, err)
+		}
+
+		fake.mu.Lock()
+		fake.requests = append(fake.requests, sqsRequest{operation: operation, input: input})
+		fake.mu.Unlock()
+
+		output := map[string]interface{}{}
+		if handler != nil {
+			if result := handler(operation, input); result != nil {
+				output = result.(map[string]interface{})
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		json.NewEncoder(w).Encode(output)
+	}))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+// queue devolve um ToSqs apontado para o fake.
+func (f *fakeSQS) queue(name string) *ToSqs {
+	q := NewToSqs("test", "test", "us-east-1", f.URL+"/000000000000/"+name)
+	q.Endpoint = f.URL
+	return q
+}
+
+func (f *fakeSQS) calls(operation string) []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var inputs []map[string]interface{}
+	for _, request := range f.requests {
+		if request.operation == operation {
+			inputs = append(inputs, request.input)
+		}
+	}
+	return inputs
+}
+
+func TestSendMessageFIFOWithoutOptionsUsesContentHash(t *testing.T) {
+	fake := newFakeSQS(t, func(operation string, input map[string]interface{}) interface{} {
+		return map[string]interface{}{"MessageId": "1"}
+	})
+	q := fake.queue("pedidos.fifo")
+
+	for i := 0; i < 2; i++ {
+		if _, err := q.SendMessage([]byte(`{"id":1}`), "pedidos"); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+	}
+	if _, err := q.SendMessage([]byte(`{"id":2}`), "pedidos"); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	sends := fake.calls("SendMessage")
+	if len(sends) != 3 {
+		t.Fatalf("SendMessage calls = %d, want 3", len(sends))
+	}
+	first, _ := sends[0]["MessageDeduplicationId"].(string)
+	if first == "" || sends[0]["MessageGroupId"] != "pedidos" {
+		t.Fatalf("first send = %v, want group and deduplication id", sends[0])
+	}
+	if sends[1]["MessageDeduplicationId"] != first {
+		t.Fatalf("retry deduplication id = %v, want %q", sends[1]["MessageDeduplicationId"], first)
+	}
+	if sends[2]["MessageDeduplicationId"] == first {
+		t.Fatal("different bodies got the same deduplication id")
+	}
+}
+
+func TestSendMessageStandardQueueOmitsFIFOFields(t *testing.T) {
+	fake := newFakeSQS(t, nil)
+	q := fake.queue("pedidos")
+
+	if _, err := q.SendMessage([]byte("a"), "", WithDeduplicationId("x")); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	send := fake.calls("SendMessage")[0]
+	if _, ok := send["MessageDeduplicationId"]; ok {
+		t.Fatalf("standard queue send = %v, want no deduplication id", send)
+	}
+	if _, ok := send["MessageGroupId"]; ok {
+		t.Fatalf("standard queue send = %v, want no group id", send)
+	}
+}
+
+func TestCreateQueueSwitchesQueueUrl(t *testing.T) {
+	var fake *fakeSQS
+	fake = newFakeSQS(t, func(operation string, input map[string]interface{}) interface{} {
+		if operation == "CreateQueue" {
+			return map[string]interface{}{"QueueUrl": fake.URL + "/000000000000/" + input["QueueName"].(string)}
+		}
+		return nil
+	})
+	q := fake.queue("antiga")
+
+	url, err := q.CreateQueue(context.Background(), "pedidos.fifo", nil)
+	if err != nil {
+		t.Fatalf("CreateQueue: %v", err)
+	}
+	if want := fake.URL + "/000000000000/pedidos.fifo"; url != want || q.QueueUrl != want {
+		t.Fatalf("CreateQueue = %q, QueueUrl = %q, want %q", url, q.QueueUrl, want)
+	}
+	create := fake.calls("CreateQueue")[0]
+	if attrs, _ := create["Attributes"].(map[string]interface{}); attrs["FifoQueue"] != "true" {
+		t.Fatalf("CreateQueue attributes = %v, want FifoQueue=true", create["Attributes"])
+	}
+
+	if _, err := q.SendMessage([]byte("a"), "g"); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if got := fake.calls("SendMessage")[0]["QueueUrl"]; got != url {
+		t.Fatalf("SendMessage QueueUrl = %v, want %q", got, url)
+	}
+}
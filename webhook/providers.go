@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// TwilioEvent representa um webhook de mensagem do Twilio (SMS/WhatsApp):
+// mensagem recebida ou callback de status.
+type TwilioEvent struct {
+	MessageSid    string
+	AccountSid    string
+	From          string
+	To            string
+	Body          string
+	MessageStatus string
+	ErrorCode     string
+	NumMedia      string
+	Params        url.Values // todos os parametros enviados pelo Twilio
+}
+
+// Type devolve o status da mensagem nos callbacks de status (sent,
+// delivered, failed...) e "incoming" para mensagens recebidas.
+func (e TwilioEvent) Type() string {
+	if e.MessageStatus != "" && e.MessageStatus != "received" {
+		return e.MessageStatus
+	}
+	return "incoming"
+}
+
+// NewTwilioReceiver valida X-Twilio-Signature e entrega TwilioEvent. Veja
+// TwilioVerifier.PublicURL para servicos atras de proxy.
+func NewTwilioReceiver(authToken, publicURL string) *Receiver[TwilioEvent] {
+	return NewReceiver(TwilioVerifier{AuthToken: authToken, PublicURL: publicURL}, decodeTwilio, TwilioEvent.Type)
+}
+
+func decodeTwilio(r *http.Request, body []byte) ([]TwilioEvent, error) {
+	params := r.URL.Query()
+	if r.Method != http.MethodGet {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		params = form
+	}
+
+	return []TwilioEvent{{
+		MessageSid:    params.Get("MessageSid"),
+		AccountSid:    params.Get("AccountSid"),
+		From:          params.Get("From"),
+		To:            params.Get("To"),
+		Body:          params.Get("Body"),
+		MessageStatus: params.Get("MessageStatus"),
+		ErrorCode:     params.Get("ErrorCode"),
+		NumMedia:      params.Get("NumMedia"),
+		Params:        params,
+	}}, nil
+}
+
+// SendGridEvent e um item do Event Webhook do SendGrid (processed, delivered,
+// open, click, bounce, dropped, spamreport...).
+type SendGridEvent struct {
+	Email       string          `json:"email"`
+	Timestamp   int64           `json:"timestamp"`
+	Event       string          `json:"event"`
+	SgEventID   string          `json:"sg_event_id"`
+	SgMessageID string          `json:"sg_message_id"`
+	Reason      string          `json:"reason,omitempty"`
+	Status      string          `json:"status,omitempty"`
+	Response    string          `json:"response,omitempty"`
+	Type        string          `json:"type,omitempty"`
+	URL         string          `json:"url,omitempty"`
+	IP          string          `json:"ip,omitempty"`
+	UserAgent   string          `json:"useragent,omitempty"`
+	Category    json.RawMessage `json:"category,omitempty"` // string ou array
+	Raw         json.RawMessage `json:"-"`                  // evento completo, com custom args
+}
+
+// NewSendGridReceiver valida o Signed Event Webhook com a chave publica em
+// base64 e entrega cada SendGridEvent do lote.
+func NewSendGridReceiver(publicKey string) (*Receiver[SendGridEvent], error) {
+	verifier, err := NewSendGridVerifier(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewReceiver(verifier, decodeSendGrid, func(e SendGridEvent) string { return e.Event }), nil
+}
+
+func decodeSendGrid(_ *http.Request, body []byte) ([]SendGridEvent, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		return nil, err
+	}
+
+	events := make([]SendGridEvent, 0, len(raws))
+	for _, raw := range raws {
+		var event SendGridEvent
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return nil, err
+		}
+		event.Raw = raw
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// SlackEvent e o envelope da Events API do Slack.
+type SlackEvent struct {
+	Token     string          `json:"token"`
+	TeamID    string          `json:"team_id"`
+	APIAppID  string          `json:"api_app_id"`
+	Type      string          `json:"type"` // url_verification, event_callback...
+	Challenge string          `json:"challenge,omitempty"`
+	EventID   string          `json:"event_id,omitempty"`
+	EventTime int64           `json:"event_time,omitempty"`
+	Event     SlackInnerEvent `json:"event"`
+}
+
+type SlackInnerEvent struct {
+	Type    string          `json:"type"` // message, app_mention, reaction_added...
+	User    string          `json:"user,omitempty"`
+	Channel string          `json:"channel,omitempty"`
+	Text    string          `json:"text,omitempty"`
+	Ts      string          `json:"ts,omitempty"`
+	Raw     json.RawMessage `json:"-"`
+}
+
+func (e *SlackInnerEvent) UnmarshalJSON(data []byte) error {
+	type plain SlackInnerEvent
+	if err := json.Unmarshal(data, (*plain)(e)); err != nil {
+		return err
+	}
+	e.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// EventType devolve o tipo do evento interno em event_callback e o tipo do
+// envelope nos demais casos.
+func (e SlackEvent) EventType() string {
+	if e.Type == "event_callback" && e.Event.Type != "" {
+		return e.Event.Type
+	}
+	return e.Type
+}
+
+// NewSlackReceiver valida a assinatura com o signing secret do app, responde
+// o desafio url_verification e entrega os eventos pelo tipo interno
+// (message, app_mention...).
+func NewSlackReceiver(signingSecret string) *Receiver[SlackEvent] {
+	receiver := NewReceiver(SlackVerifier{SigningSecret: signingSecret}, DecodeJSON[SlackEvent], SlackEvent.EventType)
+	receiver.respond = func(w http.ResponseWriter, events []SlackEvent) bool {
+		if len(events) != 1 || events[0].Type != "url_verification" {
+			return false
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(events[0].Challenge))
+		return true
+	}
+	return receiver
+}
@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AnyEvent registra um handler chamado para todos os eventos.
+const AnyEvent = "*"
+
+type HandlerFunc[T any] func(ctx context.Context, event T) error
+
+// Receiver valida a assinatura do webhook, decodifica o corpo em eventos do
+// tipo T e os entrega aos handlers registrados para o tipo de cada evento.
+type Receiver[T any] struct {
+	verifier  Verifier
+	decode    func(r *http.Request, body []byte) ([]T, error)
+	eventType func(event T) string
+	// respond permite responder diretamente certos eventos, como o desafio
+	// url_verification do Slack.
+	respond func(w http.ResponseWriter, events []T) bool
+
+	MaxBodySize int64 // padrao 1 MB
+
+	mu       sync.RWMutex
+	handlers map[string][]HandlerFunc[T]
+}
+
+// NewReceiver cria um receiver para provedores que nao tem construtor
+// proprio. verifier nil desativa a validacao de assinatura.
+func NewReceiver[T any](verifier Verifier, decode func(r *http.Request, body []byte) ([]T, error), eventType func(event T) string) *Receiver[T] {
+	return &Receiver[T]{
+		verifier:    verifier,
+		decode:      decode,
+		eventType:   eventType,
+		MaxBodySize: 1 << 20,
+		handlers:    map[string][]HandlerFunc[T]{},
+	}
+}
+
+// NewHMACReceiver recebe JSON assinado com HMAC-SHA256. O corpo pode ser um
+// evento ou um array de eventos.
+func NewHMACReceiver[T any](verifier HMACVerifier, eventType func(event T) string) *Receiver[T] {
+	return NewReceiver[T](verifier, DecodeJSON[T], eventType)
+}
+
+func (rc *Receiver[T]) On(eventType string, handler HandlerFunc[T]) *Receiver[T] {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.handlers[eventType] = append(rc.handlers[eventType], handler)
+	return rc
+}
+
+func (rc *Receiver[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, rc.MaxBodySize))
+	if err != nil {
+		http.Error(w, "invalid body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if rc.verifier != nil {
+		if err := rc.verifier.Verify(r, body); err != nil {
+			log.Printf("Webhook rejeitado em %s: %v", r.URL.Path, err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	events, err := rc.decode(r, body)
+	if err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	if rc.respond != nil && rc.respond(w, events) {
+		return
+	}
+
+	if err := rc.dispatch(r.Context(), events); err != nil {
+		log.Printf("Erro ao processar webhook em %s: %v", r.URL.Path, err)
+		// 5xx faz o provedor reenviar o evento.
+		http.Error(w, "handler error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc *Receiver[T]) EchoHandler() echo.HandlerFunc {
+	return echo.WrapHandler(rc)
+}
+
+func (rc *Receiver[T]) dispatch(ctx context.Context, events []T) error {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	var errs []error
+	for _, event := range events {
+		eventType := rc.eventType(event)
+		handlers := rc.handlers[eventType]
+		if eventType != AnyEvent {
+			handlers = append(slices.Clip(handlers), rc.handlers[AnyEvent]...)
+		}
+		for _, handler := range handlers {
+			if err := handler(ctx, event); err != nil {
+				errs = append(errs, fmt.Errorf("event %s: %w", eventType, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DecodeJSON aceita um objeto ou um array de objetos JSON.
+func DecodeJSON[T any](_ *http.Request, body []byte) ([]T, error) {
+	var events []T
+	if err := json.Unmarshal(body, &events); err == nil {
+		return events, nil
+	}
+
+	var event T
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	return []T{event}, nil
+}
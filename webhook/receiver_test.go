@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type testEvent struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+}
+
+func testEventType(e testEvent) string {
+	return e.Type
+}
+
+func serve(handler http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestReceiverDispatch(t *testing.T) {
+	var created, all []int
+	receiver := NewReceiver[testEvent](nil, DecodeJSON[testEvent], testEventType).
+		On("created", func(ctx context.Context, e testEvent) error {
+			created = append(created, e.ID)
+			return nil
+		}).
+		On(AnyEvent, func(ctx context.Context, e testEvent) error {
+			all = append(all, e.ID)
+			return nil
+		})
+
+	w := serve(receiver, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"type":"created","id":1},{"type":"deleted","id":2}]`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if len(created) != 1 || created[0] != 1 || len(all) != 2 {
+		t.Fatalf("created = %v, all = %v", created, all)
+	}
+
+	w = serve(receiver, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"type":"created","id":3}`)))
+	if w.Code != http.StatusOK || len(created) != 2 || created[1] != 3 {
+		t.Fatalf("single object: status = %d, created = %v", w.Code, created)
+	}
+}
+
+func TestReceiverResponses(t *testing.T) {
+	verifier := HMACVerifier{Secret: "segredo", Header: "X-Signature"}
+	receiver := NewHMACReceiver(verifier, testEventType).
+		On("falha", func(ctx context.Context, e testEvent) error {
+			return errors.New("erro no handler")
+		})
+	receiver.MaxBodySize = 64
+
+	request := func(body string, signed bool) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+		if signed {
+			r.Header.Set("X-Signature", hmacHex("segredo", body))
+		}
+		return r
+	}
+	tampered := request(`{"type":"ko"}`, false)
+	tampered.Header.Set("X-Signature", hmacHex("segredo", `{"type":"ok"}`))
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"ok", request(`{"type":"ok"}`, true), http.StatusOK},
+		{"unsigned", request(`{"type":"ok"}`, false), http.StatusUnauthorized},
+		{"tampered", tampered, http.StatusUnauthorized},
+		{"invalid payload", request(`nao e json`, true), http.StatusBadRequest},
+		{"handler error", request(`{"type":"falha"}`, true), http.StatusInternalServerError},
+		{"body too large", request(`{"type":"`+strings.Repeat("a", 100)+`"}`, true), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(receiver, tt.req); w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func hmacHex(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestReceiverDispatchJoinsHandlerErrors(t *testing.T) {
+	var calls int
+	receiver := NewReceiver[testEvent](nil, DecodeJSON[testEvent], testEventType).
+		On("a", func(ctx context.Context, e testEvent) error { calls++; return errors.New("primeiro") }).
+		On("a", func(ctx context.Context, e testEvent) error { calls++; return nil }).
+		On("b", func(ctx context.Context, e testEvent) error { calls++; return errors.New("segundo") })
+
+	err := receiver.dispatch(context.Background(), []testEvent{{Type: "a"}, {Type: "b"}})
+	if calls != 3 || err == nil || !strings.Contains(err.Error(), "event a: primeiro") || !strings.Contains(err.Error(), "event b: segundo") {
+		t.Fatalf("calls = %d, err = %v; want every handler called and errors joined", calls, err)
+	}
+}
+
+func TestSlackReceiver(t *testing.T) {
+	var mentions []string
+	receiver := NewSlackReceiver("segredo").On("app_mention", func(ctx context.Context, e SlackEvent) error {
+		mentions = append(mentions, e.Event.Text)
+		return nil
+	})
+
+	signed := func(body string, timestamp time.Time) *http.Request {
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		r := httptest.NewRequest(http.MethodPost, "/webhooks/slack", strings.NewReader(body))
+		r.Header.Set("X-Slack-Request-Timestamp", ts)
+		r.Header.Set("X-Slack-Signature", slackSignature("segredo", ts, []byte(body)))
+		return r
+	}
+
+	w := serve(receiver, signed(`{"type":"url_verification","challenge":"abc123"}`, time.Now()))
+	if w.Code != http.StatusOK || w.Body.String() != "abc123" {
+		t.Fatalf("challenge: status = %d, body = %q", w.Code, w.Body.String())
+	}
+
+	event := `{"type":"event_callback","event_id":"Ev1","event":{"type":"app_mention","text":"oi","user":"U1"}}`
+	if w := serve(receiver, signed(event, time.Now())); w.Code != http.StatusOK || len(mentions) != 1 || mentions[0] != "oi" {
+		t.Fatalf("event: status = %d, mentions = %v", w.Code, mentions)
+	}
+
+	if w := serve(receiver, signed(event, time.Now().Add(-10*time.Minute))); w.Code != http.StatusUnauthorized || len(mentions) != 1 {
+		t.Fatalf("replay: status = %d, mentions = %v", w.Code, mentions)
+	}
+}
+
+func TestTwilioReceiver(t *testing.T) {
+	var events []TwilioEvent
+	receiver := NewTwilioReceiver("token", "https://api.exemplo.com").
+		On("incoming", func(ctx context.Context, e TwilioEvent) error {
+			events = append(events, e)
+			return nil
+		}).
+		On("delivered", func(ctx context.Context, e TwilioEvent) error {
+			events = append(events, e)
+			return nil
+		})
+
+	post := func(form url.Values) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/webhooks/twilio", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("X-Twilio-Signature", twilioSignature("token", "https://api.exemplo.com/webhooks/twilio", form))
+		return r
+	}
+
+	incoming := url.Values{"MessageSid": {"SM1"}, "From": {"+5511"}, "Body": {"oi"}, "MessageStatus": {"received"}}
+	status := url.Values{"MessageSid": {"SM2"}, "MessageStatus": {"delivered"}}
+	for _, form := range []url.Values{incoming, status} {
+		if w := serve(receiver, post(form)); w.Code != http.StatusOK {
+			t.Fatalf("status = %d", w.Code)
+		}
+	}
+	if len(events) != 2 || events[0].Body != "oi" || events[0].Type() != "incoming" || events[1].Type() != "delivered" {
+		t.Fatalf("events = %+v", events)
+	}
+	if events[0].Params.Get("From") != "+5511" {
+		t.Fatalf("Params = %v", events[0].Params)
+	}
+}
+
+func TestSendGridReceiver(t *testing.T) {
+	key, publicKey := newSendGridKey(t)
+	receiver, err := NewSendGridReceiver(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bounces []SendGridEvent
+	receiver.On("bounce", func(ctx context.Context, e SendGridEvent) error {
+		bounces = append(bounces, e)
+		return nil
+	})
+
+	body := `[{"email":"a@exemplo.com","event":"bounce","reason":"mailbox full","pedido":"42"},{"email":"b@exemplo.com","event":"delivered"}]`
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	r := sendGridRequest(sendGridSignature(t, key, ts, []byte(body)), ts)
+	r.Body = io.NopCloser(strings.NewReader(body))
+
+	if w := serve(receiver, r); w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if len(bounces) != 1 || bounces[0].Reason != "mailbox full" || !strings.Contains(string(bounces[0].Raw), `"pedido":"42"`) {
+		t.Fatalf("bounces = %+v", bounces)
+	}
+}
+
+func TestReceiverEchoHandler(t *testing.T) {
+	var received bool
+	receiver := NewReceiver[testEvent](nil, DecodeJSON[testEvent], testEventType).
+		On("ok", func(ctx context.Context, e testEvent) error {
+			received = true
+			return nil
+		})
+
+	e := echo.New()
+	e.POST("/webhooks", receiver.EchoHandler())
+	w := serve(e, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"type":"ok"}`)))
+	if w.Code != http.StatusOK || !received {
+		t.Fatalf("status = %d, received = %v", w.Code, received)
+	}
+}
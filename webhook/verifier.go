@@ -0,0 +1,229 @@
+package webhook
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go/helpers/eventwebhook"
+	twilio "github.com/twilio/twilio-go/client"
+)
+
+var (
+	ErrMissingSignature = errors.New("webhook: missing signature")
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	ErrExpiredTimestamp = errors.New("webhook: timestamp outside tolerance")
+)
+
+// Verifier valida a assinatura de uma requisicao de webhook. body e o corpo
+// bruto, ja lido da requisicao.
+type Verifier interface {
+	Verify(r *http.Request, body []byte) error
+}
+
+type VerifierFunc func(r *http.Request, body []byte) error
+
+func (f VerifierFunc) Verify(r *http.Request, body []byte) error {
+	return f(r, body)
+}
+
+// TwilioVerifier valida o cabecalho X-Twilio-Signature.
+type TwilioVerifier struct {
+	AuthToken string
+	// PublicURL e a URL base configurada no Twilio (ex.: https://api.exemplo.com),
+	// necessaria atras de proxies. Sem ela a URL e montada a partir de Host e
+	// X-Forwarded-Proto.
+	PublicURL string
+}
+
+func (v TwilioVerifier) Verify(r *http.Request, body []byte) error {
+	signature := r.Header.Get("X-Twilio-Signature")
+	if signature == "" {
+		return ErrMissingSignature
+	}
+
+	validator := twilio.NewRequestValidator(v.AuthToken)
+	url := requestURL(r, v.PublicURL)
+
+	var valid bool
+	if r.Method == http.MethodGet {
+		valid = validator.Validate(url, map[string]string{}, signature)
+	} else {
+		valid = validator.ValidateBody(url, body, signature)
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SendGridVerifier valida o Signed Event Webhook do SendGrid (ECDSA).
+type SendGridVerifier struct {
+	publicKey *ecdsa.PublicKey
+	Tolerance time.Duration // padrao 0 (sem checagem do timestamp)
+}
+
+// NewSendGridVerifier recebe a chave publica em base64, como exibida no
+// painel do SendGrid.
+func NewSendGridVerifier(publicKey string) (*SendGridVerifier, error) {
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: invalid sendgrid public key: %w", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: invalid sendgrid public key: %w", err)
+	}
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("webhook: sendgrid public key is not ECDSA")
+	}
+	return &SendGridVerifier{publicKey: ecdsaKey}, nil
+}
+
+func (v *SendGridVerifier) Verify(r *http.Request, body []byte) error {
+	signature := r.Header.Get(eventwebhook.VerificationHTTPHeader)
+	timestamp := r.Header.Get(eventwebhook.TimestampHTTPHeader)
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+	if err := checkTimestamp(timestamp, v.Tolerance); err != nil {
+		return err
+	}
+
+	valid, err := eventwebhook.VerifySignature(v.publicKey, body, signature, timestamp)
+	if err != nil || !valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SlackVerifier valida X-Slack-Signature com o signing secret do app e
+// rejeita requisicoes antigas para evitar replay.
+type SlackVerifier struct {
+	SigningSecret string
+	Tolerance     time.Duration // padrao 5 minutos
+}
+
+func (v SlackVerifier) Verify(r *http.Request, body []byte) error {
+	signature := r.Header.Get("X-Slack-Signature")
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+
+	tolerance := v.Tolerance
+	if tolerance <= 0 {
+		tolerance = 5 * time.Minute
+	}
+	if err := checkTimestamp(timestamp, tolerance); err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, []byte(v.SigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// HMACVerifier valida assinaturas HMAC-SHA256 genericas, como as usadas por
+// GitHub, Stripe e servicos internos.
+type HMACVerifier struct {
+	Secret   string
+	Header   string // cabecalho da assinatura, ex.: X-Hub-Signature-256
+	Prefix   string // prefixo removido da assinatura, ex.: "sha256="
+	Encoding string // "hex" (padrao) ou "base64"
+	// TimestampHeader, quando informado, e checado contra Tolerance e o
+	// conteudo assinado passa a ser "<timestamp>.<corpo>".
+	TimestampHeader string
+	Tolerance       time.Duration // padrao 5 minutos
+}
+
+func (v HMACVerifier) Verify(r *http.Request, body []byte) error {
+	signature := strings.TrimPrefix(r.Header.Get(v.Header), v.Prefix)
+	if signature == "" {
+		return ErrMissingSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(v.Secret))
+	if v.TimestampHeader != "" {
+		timestamp := r.Header.Get(v.TimestampHeader)
+		if timestamp == "" {
+			return ErrMissingSignature
+		}
+		tolerance := v.Tolerance
+		if tolerance <= 0 {
+			tolerance = 5 * time.Minute
+		}
+		if err := checkTimestamp(timestamp, tolerance); err != nil {
+			return err
+		}
+		mac.Write([]byte(timestamp + "."))
+	}
+	mac.Write(body)
+
+	var received []byte
+	var err error
+	if v.Encoding == "base64" {
+		received, err = base64.StdEncoding.DecodeString(signature)
+	} else {
+		received, err = hex.DecodeString(signature)
+	}
+	if err != nil || !hmac.Equal(received, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// checkTimestamp aceita timestamps Unix em segundos; tolerance 0 desativa.
+func checkTimestamp(value string, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		return nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	diff := time.Since(time.Unix(seconds, 0))
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > tolerance {
+		return ErrExpiredTimestamp
+	}
+	return nil
+}
+
+func requestURL(r *http.Request, publicURL string) string {
+	if publicURL != "" {
+		return strings.TrimRight(publicURL, "/") + r.URL.RequestURI()
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+	return scheme + "://" + host + r.URL.RequestURI()
+}
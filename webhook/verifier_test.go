@@ -0,0 +1,273 @@
+package webhook
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func twilioSignature(token, fullURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	data := fullURL
+	for _, key := range keys {
+		data += key + params.Get(key)
+	}
+	mac := hmac.New(sha1.New, []byte(token))
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestTwilioVerifier(t *testing.T) {
+	form := url.Values{"From": {"+5511999999999"}, "Body": {"oi"}, "MessageSid": {"SM1"}}
+	body := []byte(form.Encode())
+	verifier := TwilioVerifier{AuthToken: "token", PublicURL: "https://api.exemplo.com"}
+
+	newRequest := func(signature string, body []byte) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "http://interno:8080/webhooks/twilio?conta=1", strings.NewReader(string(body)))
+		if signature != "" {
+			r.Header.Set("X-Twilio-Signature", signature)
+		}
+		return r
+	}
+	valid := twilioSignature("token", "https://api.exemplo.com/webhooks/twilio?conta=1", form)
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		body []byte
+		want error
+	}{
+		{"valid", newRequest(valid, body), body, nil},
+		{"missing", newRequest("", body), body, ErrMissingSignature},
+		{"tampered body", newRequest(valid, []byte(form.Encode()+"&Extra=1")), []byte(form.Encode() + "&Extra=1"), ErrInvalidSignature},
+		{"wrong token", newRequest(twilioSignature("outro", "https://api.exemplo.com/webhooks/twilio?conta=1", form), body), body, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifier.Verify(tt.req, tt.body); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("forwarded headers without PublicURL", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/webhooks/twilio?MessageSid=SM1", nil)
+		r.Host = "interno:8080"
+		r.Header.Set("X-Forwarded-Proto", "https")
+		r.Header.Set("X-Forwarded-Host", "api.exemplo.com")
+		r.Header.Set("X-Twilio-Signature", twilioSignature("token", "https://api.exemplo.com/webhooks/twilio?MessageSid=SM1", nil))
+		if err := (TwilioVerifier{AuthToken: "token"}).Verify(r, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func newSendGridKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, base64.StdEncoding.EncodeToString(der)
+}
+
+func sendGridSignature(t *testing.T, key *ecdsa.PrivateKey, timestamp string, body []byte) string {
+	t.Helper()
+	hash := sha256.Sum256(append([]byte(timestamp), body...))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func sendGridRequest(signature, timestamp string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/sendgrid", nil)
+	if signature != "" {
+		r.Header.Set("X-Twilio-Email-Event-Webhook-Signature", signature)
+	}
+	if timestamp != "" {
+		r.Header.Set("X-Twilio-Email-Event-Webhook-Timestamp", timestamp)
+	}
+	return r
+}
+
+func TestSendGridVerifier(t *testing.T) {
+	key, publicKey := newSendGridKey(t)
+	verifier, err := NewSendGridVerifier(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte(`[{"email":"a@exemplo.com","event":"bounce"}]`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := sendGridSignature(t, key, now, body)
+
+	if err := verifier.Verify(sendGridRequest(signature, now), body); err != nil {
+		t.Fatalf("valid signature: %v", err)
+	}
+	if err := verifier.Verify(sendGridRequest(signature, now), []byte(`[{"event":"delivered"}]`)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("tampered body: err = %v", err)
+	}
+	if err := verifier.Verify(sendGridRequest("bm90LWFzbjE=", now), body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("malformed signature: err = %v", err)
+	}
+	if err := verifier.Verify(sendGridRequest(signature, ""), body); !errors.Is(err, ErrMissingSignature) {
+		t.Fatalf("missing timestamp: err = %v", err)
+	}
+
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	staleSignature := sendGridSignature(t, key, stale, body)
+	if err := verifier.Verify(sendGridRequest(staleSignature, stale), body); err != nil {
+		t.Fatalf("tolerance 0 should accept old timestamps: %v", err)
+	}
+	verifier.Tolerance = 5 * time.Minute
+	if err := verifier.Verify(sendGridRequest(staleSignature, stale), body); !errors.Is(err, ErrExpiredTimestamp) {
+		t.Fatalf("stale timestamp: err = %v", err)
+	}
+
+	if _, err := NewSendGridVerifier("nao-e-base64!"); err == nil {
+		t.Fatal("expected error for invalid public key")
+	}
+}
+
+func slackSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func slackRequest(signature, timestamp string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/slack", nil)
+	if signature != "" {
+		r.Header.Set("X-Slack-Signature", signature)
+	}
+	if timestamp != "" {
+		r.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	}
+	return r
+}
+
+func TestSlackVerifier(t *testing.T) {
+	verifier := SlackVerifier{SigningSecret: "segredo"}
+	body := []byte(`{"type":"event_callback"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	tests := []struct {
+		name      string
+		signature string
+		timestamp string
+		want      error
+	}{
+		{"valid", slackSignature("segredo", now, body), now, nil},
+		{"missing signature", "", now, ErrMissingSignature},
+		{"missing timestamp", slackSignature("segredo", now, body), "", ErrMissingSignature},
+		{"wrong secret", slackSignature("outro", now, body), now, ErrInvalidSignature},
+		{"timestamp not signed", slackSignature("segredo", now, body), strconv.FormatInt(time.Now().Unix()-1, 10), ErrInvalidSignature},
+		{"invalid timestamp", slackSignature("segredo", "abc", body), "abc", ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifier.Verify(slackRequest(tt.signature, tt.timestamp), body); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("replay outside tolerance", func(t *testing.T) {
+		for _, offset := range []time.Duration{-6 * time.Minute, 6 * time.Minute} {
+			ts := strconv.FormatInt(time.Now().Add(offset).Unix(), 10)
+			if err := verifier.Verify(slackRequest(slackSignature("segredo", ts, body), ts), body); !errors.Is(err, ErrExpiredTimestamp) {
+				t.Fatalf("offset %s: err = %v, want ErrExpiredTimestamp", offset, err)
+			}
+		}
+
+		ts := strconv.FormatInt(time.Now().Add(-6*time.Minute).Unix(), 10)
+		custom := SlackVerifier{SigningSecret: "segredo", Tolerance: 10 * time.Minute}
+		if err := custom.Verify(slackRequest(slackSignature("segredo", ts, body), ts), body); err != nil {
+			t.Fatalf("custom tolerance: %v", err)
+		}
+	})
+}
+
+func TestHMACVerifier(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	sign := func(data []byte) []byte {
+		mac := hmac.New(sha256.New, []byte("segredo"))
+		mac.Write(data)
+		return mac.Sum(nil)
+	}
+	request := func(headers map[string]string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/webhooks/github", nil)
+		for key, value := range headers {
+			r.Header.Set(key, value)
+		}
+		return r
+	}
+
+	github := HMACVerifier{Secret: "segredo", Header: "X-Hub-Signature-256", Prefix: "sha256="}
+	valid := "sha256=" + hex.EncodeToString(sign(body))
+	if err := github.Verify(request(map[string]string{"X-Hub-Signature-256": valid}), body); err != nil {
+		t.Fatalf("valid hex: %v", err)
+	}
+	if err := github.Verify(request(map[string]string{"X-Hub-Signature-256": valid}), []byte(`{"action":"closed"}`)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("tampered body: err = %v", err)
+	}
+	if err := github.Verify(request(map[string]string{"X-Hub-Signature-256": "sha256=zz"}), body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("invalid hex: err = %v", err)
+	}
+	if err := github.Verify(request(nil), body); !errors.Is(err, ErrMissingSignature) {
+		t.Fatalf("missing: err = %v", err)
+	}
+
+	b64 := HMACVerifier{Secret: "segredo", Header: "X-Signature", Encoding: "base64"}
+	if err := b64.Verify(request(map[string]string{"X-Signature": base64.StdEncoding.EncodeToString(sign(body))}), body); err != nil {
+		t.Fatalf("valid base64: %v", err)
+	}
+
+	timed := HMACVerifier{Secret: "segredo", Header: "X-Signature", TimestampHeader: "X-Timestamp"}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	signed := hex.EncodeToString(sign(append([]byte(now+"."), body...)))
+	if err := timed.Verify(request(map[string]string{"X-Signature": signed, "X-Timestamp": now}), body); err != nil {
+		t.Fatalf("valid timestamped: %v", err)
+	}
+	if err := timed.Verify(request(map[string]string{"X-Signature": signed}), body); !errors.Is(err, ErrMissingSignature) {
+		t.Fatalf("missing timestamp: err = %v", err)
+	}
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	staleSigned := hex.EncodeToString(sign(append([]byte(stale+"."), body...)))
+	if err := timed.Verify(request(map[string]string{"X-Signature": staleSigned, "X-Timestamp": stale}), body); !errors.Is(err, ErrExpiredTimestamp) {
+		t.Fatalf("stale timestamp: err = %v", err)
+	}
+}